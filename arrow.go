@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+)
+
+// arrowColumn is one column of the Arrow output: its schema field and how
+// to append a hotel's value to the column's builder.
+type arrowColumn struct {
+	field arrow.Field
+	add   func(array.Builder, Hotel)
+}
+
+func arrowString(name string, get func(Hotel) string) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.BinaryTypes.String},
+		add:   func(b array.Builder, h Hotel) { b.(*array.StringBuilder).Append(get(h)) },
+	}
+}
+
+func arrowFloat64(name string, get func(Hotel) float64) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.PrimitiveTypes.Float64},
+		add:   func(b array.Builder, h Hotel) { b.(*array.Float64Builder).Append(get(h)) },
+	}
+}
+
+func arrowInt64(name string, get func(Hotel) int64) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.PrimitiveTypes.Int64},
+		add:   func(b array.Builder, h Hotel) { b.(*array.Int64Builder).Append(get(h)) },
+	}
+}
+
+func arrowBool(name string, get func(Hotel) bool) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.FixedWidthTypes.Boolean},
+		add:   func(b array.Builder, h Hotel) { b.(*array.BooleanBuilder).Append(get(h)) },
+	}
+}
+
+// arrowOptionalBool writes null for a nil value.
+func arrowOptionalBool(name string, get func(Hotel) *bool) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+		add: func(b array.Builder, h Hotel) {
+			if v := get(h); v != nil {
+				b.(*array.BooleanBuilder).Append(*v)
+			} else {
+				b.(*array.BooleanBuilder).AppendNull()
+			}
+		},
+	}
+}
+
+func arrowInt32(name string, get func(Hotel) int) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.PrimitiveTypes.Int32},
+		add:   func(b array.Builder, h Hotel) { b.(*array.Int32Builder).Append(int32(get(h))) },
+	}
+}
+
+// arrowDate stores a YYYY-MM-DD string as Date32, or null when it does not
+// parse.
+func arrowDate(name string, get func(Hotel) string) arrowColumn {
+	return arrowColumn{
+		field: arrow.Field{Name: name, Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+		add: func(b array.Builder, h Hotel) {
+			day, err := time.Parse("2006-01-02", get(h))
+			if err != nil {
+				b.AppendNull()
+				return
+			}
+			b.(*array.Date32Builder).Append(arrow.Date32FromTime(day))
+		},
+	}
+}
+
+// arrowColumns uses the same snake_case names as the Parquet output.
+var arrowColumns = []arrowColumn{
+	arrowString("name", func(h Hotel) string { return h.Name }),
+	arrowString("price", func(h Hotel) string { return h.Price }),
+	arrowFloat64("price_numeric", func(h Hotel) float64 { return h.PriceNumeric }),
+	arrowDate("check_in", func(h Hotel) string { return h.CheckIn }),
+	arrowDate("check_out", func(h Hotel) string { return h.CheckOut }),
+	arrowString("rating", func(h Hotel) string { return h.Rating }),
+	arrowString("num_reviews", func(h Hotel) string { return h.NumReviews }),
+	arrowString("address", func(h Hotel) string { return h.Address }),
+	arrowString("amenities", func(h Hotel) string { return h.Amenities }),
+	arrowString("room_type", func(h Hotel) string { return h.RoomType }),
+	arrowString("cancellation", func(h Hotel) string { return h.Cancellation }),
+	arrowString("distance", func(h Hotel) string { return h.Distance }),
+	arrowInt32("distance_meters", func(h Hotel) int { return h.DistanceMeters }),
+	arrowString("property_type", func(h Hotel) string { return h.PropertyType }),
+	arrowString("star_rating", func(h Hotel) string { return h.StarRating }),
+	arrowInt32("star_rating_int", func(h Hotel) int { return h.StarRatingInt }),
+	arrowString("booking_url", func(h Hotel) string { return h.BookingURL }),
+	arrowString("photos", func(h Hotel) string { return h.Photos }),
+	arrowString("guest_score_break", func(h Hotel) string { return h.GuestScoreBreak }),
+	arrowString("description", func(h Hotel) string { return h.Description }),
+	arrowInt32("adults", func(h Hotel) int { return h.Adults }),
+	arrowInt32("children", func(h Hotel) int { return h.Children }),
+	arrowInt32("rooms", func(h Hotel) int { return h.Rooms }),
+	arrowString("currency", func(h Hotel) string { return h.Currency }),
+	arrowString("house_rules", func(h Hotel) string { return h.HouseRules }),
+	arrowString("language", func(h Hotel) string { return h.Language }),
+	arrowString("scraped_at", func(h Hotel) string { return h.ScrapedAt }),
+	arrowString("run_id", func(h Hotel) string { return h.RunID }),
+	arrowString("search_city", func(h Hotel) string { return h.SearchCity }),
+	arrowInt64("extraction_ms", func(h Hotel) int64 { return h.ExtractionMs }),
+	arrowFloat64("rating_value", func(h Hotel) float64 { return h.RatingValue }),
+	arrowInt32("review_count", func(h Hotel) int { return h.ReviewCount }),
+	arrowBool("has_reviews", func(h Hotel) bool { return h.HasReviews }),
+	arrowString("star_rating_type", func(h Hotel) string { return h.StarRatingType }),
+	arrowString("property_id", func(h Hotel) string { return h.PropertyID }),
+	arrowString("canonical_url", func(h Hotel) string { return h.CanonicalURL }),
+	arrowFloat64("latitude", func(h Hotel) float64 { return h.Latitude }),
+	arrowFloat64("longitude", func(h Hotel) float64 { return h.Longitude }),
+	arrowOptionalBool("free_cancellation", func(h Hotel) *bool { return h.FreeCancellation }),
+	arrowOptionalBool("no_prepayment", func(h Hotel) *bool { return h.NoPrepayment }),
+	arrowString("price_currency", func(h Hotel) string { return h.PriceCurrency }),
+}
+
+var arrowSchema = func() *arrow.Schema {
+	fields := make([]arrow.Field, len(arrowColumns))
+	for i, c := range arrowColumns {
+		fields[i] = c.field
+	}
+	return arrow.NewSchema(fields, nil)
+}()
+
+// ExportToArrow writes hotels as one record batch in the Arrow IPC file
+// format, which pyarrow, Pandas and Polars read directly.
+func ExportToArrow(hotels Hotels, w io.Writer) error {
+	mem := memory.NewGoAllocator()
+	builder := array.NewRecordBuilder(mem, arrowSchema)
+	defer builder.Release()
+
+	for _, hotel := range hotels {
+		for i, c := range arrowColumns {
+			c.add(builder.Field(i), hotel)
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	writer, err := ipc.NewFileWriter(&offsetWriter{w: w}, ipc.WithSchema(arrowSchema), ipc.WithAllocator(mem))
+	if err != nil {
+		return fmt.Errorf("error starting Arrow file: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		writer.Close()
+		return fmt.Errorf("error writing Arrow record: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error finishing Arrow file: %w", err)
+	}
+	return nil
+}
+
+// offsetWriter lets ipc.NewFileWriter write to a plain io.Writer, such as a
+// gzip stream. The file writer only seeks to ask for the current offset.
+type offsetWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.n += int64(n)
+	return n, err
+}
+
+func (o *offsetWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return o.n, errors.New("arrow output is not seekable")
+	}
+	return o.n, nil
+}
+
+// ArrowWriter is the HotelWriter for -format arrow.
+type ArrowWriter struct{}
+
+func (ArrowWriter) Ext() string { return "arrow" }
+
+func (ArrowWriter) Write(w io.Writer, hotels []Hotel) error {
+	return ExportToArrow(hotels, w)
+}
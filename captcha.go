@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	twoCaptchaSubmitURL = "https://2captcha.com/in.php"
+	twoCaptchaResultURL = "https://2captcha.com/res.php"
+)
+
+var captchaHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// twoCaptchaResponse is the JSON envelope both 2captcha endpoints reply with.
+// Request holds the task ID, the solved token or an error code depending on
+// the call and Status.
+type twoCaptchaResponse struct {
+	Status  int    `json:"status"`
+	Request string `json:"request"`
+}
+
+// solveCAPTCHAViaAPI sends the page's reCAPTCHA to 2captcha, waits for a
+// worker to solve it and injects the returned token into the page. It gives
+// up when ctx is done, so -city-timeout and Ctrl+C stop the wait.
+func solveCAPTCHAViaAPI(ctx context.Context, page playwright.Page, sel Selectors, apiKey string) error {
+	siteKey, err := recaptchaSiteKey(page, sel)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"key":       {apiKey},
+		"method":    {"userrecaptcha"},
+		"googlekey": {siteKey},
+		"pageurl":   {page.URL()},
+		"json":      {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, twoCaptchaSubmitURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("could not create CAPTCHA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	submitted, err := twoCaptchaCall(captchaHTTPClient.Do(req))
+	if err != nil {
+		return fmt.Errorf("could not submit CAPTCHA: %w", err)
+	}
+	if submitted.Status != 1 {
+		return fmt.Errorf("2captcha rejected the CAPTCHA: %s", submitted.Request)
+	}
+	infof("CAPTCHA submitted to 2captcha (task %s)", submitted.Request)
+
+	query := url.Values{
+		"key":    {apiKey},
+		"action": {"get"},
+		"id":     {submitted.Request},
+		"json":   {"1"},
+	}
+	resultURL := twoCaptchaResultURL + "?" + query.Encode()
+
+	// 2captcha asks clients to wait at least 5 seconds between polls; solves
+	// normally take 15-45 seconds.
+	deadline := time.NewTimer(3 * time.Minute)
+	defer deadline.Stop()
+	var token string
+	for token == "" {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-deadline.C:
+			return errors.New("timed out waiting for 2captcha solution")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, resultURL, nil)
+		if err != nil {
+			return fmt.Errorf("could not create CAPTCHA request: %w", err)
+		}
+		result, err := twoCaptchaCall(captchaHTTPClient.Do(req))
+		if err != nil {
+			return fmt.Errorf("could not poll CAPTCHA result: %w", err)
+		}
+		switch {
+		case result.Status == 1:
+			token = result.Request
+		case result.Request != "CAPCHA_NOT_READY":
+			return fmt.Errorf("2captcha could not solve the CAPTCHA: %s", result.Request)
+		}
+	}
+
+	if _, err := page.Evaluate(`(token) => {
+		document.querySelectorAll('[name="g-recaptcha-response"]').forEach((el) => {
+			el.value = token;
+			el.innerHTML = token;
+		});
+		const el = document.querySelector('[data-callback]');
+		const callback = el && window[el.getAttribute('data-callback')];
+		if (typeof callback === 'function') {
+			callback(token);
+		}
+	}`, token); err != nil {
+		return fmt.Errorf("could not inject CAPTCHA token: %w", err)
+	}
+	return nil
+}
+
+// recaptchaSiteKey finds the reCAPTCHA site key, either on the widget's
+// data-sitekey attribute or in the k parameter of the iframe URL.
+func recaptchaSiteKey(page playwright.Page, sel Selectors) (string, error) {
+	if element, err := page.QuerySelector("[data-sitekey]"); err == nil && element != nil {
+		if key, err := element.GetAttribute("data-sitekey"); err == nil && key != "" {
+			return key, nil
+		}
+	}
+
+	if iframe, err := page.QuerySelector(sel.CAPTCHAFrame); err == nil && iframe != nil {
+		src, err := iframe.GetAttribute("src")
+		if err == nil {
+			if u, err := url.Parse(src); err == nil && u.Query().Get("k") != "" {
+				return u.Query().Get("k"), nil
+			}
+		}
+	}
+	return "", errors.New("could not find reCAPTCHA site key")
+}
+
+func twoCaptchaCall(resp *http.Response, err error) (twoCaptchaResponse, error) {
+	if err != nil {
+		return twoCaptchaResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return twoCaptchaResponse{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var result twoCaptchaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return twoCaptchaResponse{}, fmt.Errorf("could not decode response: %w", err)
+	}
+	result.Request = strings.TrimSpace(result.Request)
+	return result, nil
+}
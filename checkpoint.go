@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type CheckpointStatus string
+
+const (
+	CheckpointPending    CheckpointStatus = "pending"
+	CheckpointInProgress CheckpointStatus = "in-progress"
+	CheckpointDone       CheckpointStatus = "done"
+)
+
+// CheckpointStore records the status of every (city, stay) job in a
+// JSON file so an interrupted run can be resumed without scraping finished
+// jobs again. It is safe for concurrent use.
+type CheckpointStore struct {
+	mu     sync.Mutex
+	path   string
+	status map[string]CheckpointStatus
+}
+
+// LoadCheckpointStore reads the checkpoint file at path. A missing file, or
+// force being set, starts from an empty state.
+func LoadCheckpointStore(path string, force bool) (*CheckpointStore, error) {
+	c := &CheckpointStore{path: path, status: make(map[string]CheckpointStatus)}
+	if force {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint file: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.status); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// checkpointKey identifies a (city, stay) job in the checkpoint file.
+func checkpointKey(city string, stay Stay) string {
+	return city + "@" + stay.String()
+}
+
+func (c *CheckpointStore) Status(key string) CheckpointStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status, ok := c.status[key]; ok {
+		return status
+	}
+	return CheckpointPending
+}
+
+// Set updates the status of key and rewrites the checkpoint file.
+func (c *CheckpointStore) Set(key string, status CheckpointStatus) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status[key] = status
+
+	data, err := json.MarshalIndent(c.status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode checkpoints: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return fmt.Errorf("could not create checkpoint directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash mid-write cannot leave a
+	// truncated checkpoint behind.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("could not write checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("could not replace checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var cityStatsHeader = []string{"City", "Stay", "Properties", "Extracted", "AvgPrice", "MedianPrice", "MinPrice", "MaxPrice", "AvgRating", "FreeCancellationPercent", "Duration"}
+
+// CityStatsFile collects one row of statistics per finished job in
+// <dir>/<date>/summary_<runID>.csv. Jobs of concurrent cities append to it,
+// hence the mutex; every row is flushed right away so the file is useful
+// while the run is still going. listCSVFiles skips summary files, so diff
+// and validate never mistake one for scraped data.
+type CityStatsFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	path   string
+}
+
+func NewCityStatsFile(dir, runID string) (*CityStatsFile, error) {
+	path := filepath.Join(dir, time.Now().Format("2006-01-02"), "summary_"+runID+".csv")
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("could not create data directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create city summary: %w", err)
+	}
+
+	s := &CityStatsFile{file: file, writer: csv.NewWriter(file), path: path}
+	if err := s.write(cityStatsHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add appends the statistics of one job. properties is the count the
+// search page reported. Prices and ratings that were not parsed are left out
+// of the averages; columns with no values at all stay empty.
+func (s *CityStatsFile) Add(city string, stay Stay, properties int, hotels Hotels, duration time.Duration) error {
+	var prices, ratings []float64
+	freeCancellation := 0
+	for _, hotel := range hotels {
+		if hotel.PriceNumeric > 0 {
+			prices = append(prices, hotel.PriceNumeric)
+		}
+		if hotel.HasReviews {
+			ratings = append(ratings, hotel.RatingValue)
+		}
+		if strings.Contains(strings.ToLower(hotel.Cancellation), "free cancellation") {
+			freeCancellation++
+		}
+	}
+	sort.Float64s(prices)
+
+	number := func(v float64, ok bool) string {
+		if !ok {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	lowest, highest, average := hotels.PriceStats()
+	percent := 0.0
+	if len(hotels) > 0 {
+		percent = 100 * float64(freeCancellation) / float64(len(hotels))
+	}
+
+	return s.write([]string{
+		city, stay.String(), strconv.Itoa(properties), strconv.Itoa(len(hotels)),
+		number(average, len(prices) > 0), number(median(prices), len(prices) > 0),
+		number(lowest, len(prices) > 0), number(highest, len(prices) > 0),
+		number(mean(ratings), len(ratings) > 0), number(percent, len(hotels) > 0),
+		duration.Round(time.Second).String(),
+	})
+}
+
+func (s *CityStatsFile) write(row []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.writer.Write(row); err != nil {
+		return fmt.Errorf("error writing city summary: %w", err)
+	}
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return fmt.Errorf("error writing city summary: %w", err)
+	}
+	return nil
+}
+
+// Path returns the location of the summary file.
+func (s *CityStatsFile) Path() string {
+	return s.path
+}
+
+func (s *CityStatsFile) Close() error {
+	return s.file.Close()
+}
+
+// median returns the middle of sorted values, or NaN for none.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	switch {
+	case n == 0:
+		return math.NaN()
+	case n%2 == 1:
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mean returns the average of values, or NaN for none.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CombinedOutput collects the CSV rows of every city in one file with an
+// extra City column. Cities finish concurrently, so each one's rows are
+// encoded up front and appended in a single write under the mutex; a city
+// that fails before exporting never touches the file.
+type CombinedOutput struct {
+	mu     sync.Mutex
+	file   *os.File
+	path   string
+	format CSVWriter
+}
+
+// combinedFilePath returns <dir>/<date>/all_cities_hotels_<timestamp>.<ext>.
+func combinedFilePath(dir, ext string) string {
+	now := time.Now()
+	return filepath.Join(dir, now.Format("2006-01-02"), "all_cities_hotels_"+now.Format("15-04-05")+"."+ext)
+}
+
+// NewCombinedOutput creates the combined file and writes its header in the
+// given format.
+func NewCombinedOutput(dir string, format CSVWriter) (*CombinedOutput, error) {
+	path := combinedFilePath(dir, format.Ext())
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("could not create data directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create file: %w", err)
+	}
+
+	c := &CombinedOutput{file: file, path: path, format: format}
+	if format.BOM {
+		if _, err := file.WriteString(utf8BOM); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error writing BOM to combined CSV: %w", err)
+		}
+	}
+	if err := c.write([][]string{append([]string{"City"}, format.Columns.Header()...)}); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error writing header to combined CSV: %w", err)
+	}
+	return c, nil
+}
+
+// Write appends the rows for one city.
+func (c *CombinedOutput) Write(city string, hotels []Hotel) error {
+	rows := make([][]string, len(hotels))
+	for i, hotel := range hotels {
+		rows[i] = append([]string{city}, c.format.row(hotel)...)
+	}
+	if err := c.write(rows); err != nil {
+		return fmt.Errorf("error writing %s to combined CSV: %w", city, err)
+	}
+	return nil
+}
+
+func (c *CombinedOutput) Name() string { return "combined CSV" }
+
+// Save makes CombinedOutput a HotelSink.
+func (c *CombinedOutput) Save(city string, hotels []Hotel) (string, error) {
+	if err := c.Write(city, hotels); err != nil {
+		return "", err
+	}
+	return c.path, nil
+}
+
+func (c *CombinedOutput) write(rows [][]string) error {
+	var buf bytes.Buffer
+	writer := c.format.newWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.file.Write(buf.Bytes())
+	return err
+}
+
+// Path returns the location of the combined file.
+func (c *CombinedOutput) Path() string {
+	return c.path
+}
+
+func (c *CombinedOutput) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// main runs the command named by the first argument. Anything else is
+// handed to scrape, so invocations from before there were commands keep
+// working.
+func main() {
+	root := newRootCommand()
+	args := os.Args[1:]
+	if len(args) == 0 || !isCommand(root, args[0]) {
+		args = append([]string{"scrape"}, args...)
+	}
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          filepath.Base(os.Args[0]),
+		Short:        "Scrape Booking.com hotel listings and work with the results",
+		SilenceUsage: true,
+	}
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "scrape [flags] [city...]",
+			Short: "Scrape hotels for a list of cities (the default command)",
+			// scrape and diff keep their standard library flag sets, which
+			// -config and -print-config are built on.
+			DisableFlagParsing: true,
+			Run: func(cmd *cobra.Command, args []string) {
+				runScrape(args)
+			},
+		},
+		&cobra.Command{
+			Use:                "diff [-o file] OLD NEW",
+			Short:              "Compare prices between two runs",
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runDiff(args)
+			},
+		},
+		newExportCommand(),
+		newValidateCommand(),
+		newServeCommand(),
+	)
+	return root
+}
+
+// isCommand reports whether name selects a command rather than being a
+// scrape flag or city. cobra adds help and completion itself.
+func isCommand(root *cobra.Command, name string) bool {
+	if name == "help" || name == "completion" {
+		return true
+	}
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile applies a YAML config file to the flags in fs. Keys are flag
+// names and values use the same syntax as on the command line, except that
+// lists may also be written as YAML sequences. Flags given on the command
+// line take precedence over the file, and unknown keys are an error so typos
+// do not go unnoticed. The special "selectors" key overrides entries of sel.
+func loadConfigFile(path string, fs *flag.FlagSet, sel *Selectors) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	for name, value := range values {
+		if name == "selectors" {
+			if err := decodeSelectors(value, sel); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+		if fs.Lookup(name) == nil || name == "config" || name == "print-config" {
+			return fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+		if setOnCommandLine[name] {
+			continue
+		}
+		if err := fs.Set(name, configValueString(value)); err != nil {
+			return fmt.Errorf("%s: invalid value for %q: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// decodeSelectors merges the selectors section of a config file into sel.
+// Entries that are not mentioned keep their current value.
+func decodeSelectors(value any, sel *Selectors) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid selectors: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(sel); err != nil {
+		return fmt.Errorf("invalid selectors: %w", err)
+	}
+	return nil
+}
+
+// configValueString renders a decoded YAML value the way it would be written
+// on the command line.
+func configValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = configValueString(item)
+		}
+		return strings.Join(parts, ",")
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// printConfig writes the fully resolved settings as a YAML config file, so
+// the output can be saved and passed back with -config.
+func printConfig(fs *flag.FlagSet, sel Selectors) error {
+	var names []string
+	values := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" {
+			return
+		}
+		names = append(names, f.Name)
+		values[f.Name] = f.Value.String()
+	})
+	sort.Strings(names)
+
+	for _, name := range names {
+		out, err := yaml.Marshal(map[string]string{name: values[name]})
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+	}
+
+	out, err := yaml.Marshal(map[string]Selectors{"selectors": sel})
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(out))
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigFileFlagsWin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+cities: [Austin, Dallas]
+concurrency: 5
+headless: true
+currency: EUR
+`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		cities   string
+		workers  int
+		headless bool
+		currency string
+	}{
+		{"file only", nil, "Austin,Dallas", 5, true, "EUR"},
+		{"flag wins", []string{"-concurrency", "2"}, "Austin,Dallas", 2, true, "EUR"},
+		{"flag set to its default wins", []string{"-headless=false", "-currency", ""}, "Austin,Dallas", 5, false, ""},
+		{"all flags", []string{"-cities", "Houston", "-concurrency", "1", "-headless=false", "-currency", "USD"}, "Houston", 1, false, "USD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cities := fs.String("cities", "", "")
+			workers := fs.Int("concurrency", 3, "")
+			headless := fs.Bool("headless", false, "")
+			currency := fs.String("currency", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := loadConfigFile(path, fs, &Selectors{}); err != nil {
+				t.Fatalf("loadConfigFile: %v", err)
+			}
+			if *cities != tt.cities || *workers != tt.workers || *headless != tt.headless || *currency != tt.currency {
+				t.Errorf("got cities %q, concurrency %d, headless %v, currency %q; want %q, %d, %v, %q",
+					*cities, *workers, *headless, *currency, tt.cities, tt.workers, tt.headless, tt.currency)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("concurency: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("concurrency", 3, "")
+	if err := loadConfigFile(path, fs, &Selectors{}); err == nil {
+		t.Error("loadConfigFile accepted a misspelled key")
+	}
+}
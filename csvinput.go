@@ -0,0 +1,256 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// listCSVFiles returns every output CSV under path in name order, or path
+// itself when it is a file. Streamed .partial files and city summaries are
+// skipped.
+func listCSVFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() || strings.Contains(name, ".partial.") || strings.HasPrefix(name, "summary_") {
+			return nil
+		}
+		for _, ext := range []string{".csv", ".tsv", ".csv.gz", ".tsv.gz"} {
+			if strings.HasSuffix(name, ext) {
+				files = append(files, p)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s: %w", path, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// csvFile is an output CSV opened for reading, with its header indexed by
+// column name.
+type csvFile struct {
+	*csv.Reader
+	Header []string
+	column map[string]int
+	closer []io.Closer
+}
+
+// openCSV opens a file written by CSVWriter: gzipped files and .tsv are
+// recognized by name and a leading byte order mark is dropped.
+func openCSV(path string) (*csvFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	closer := []io.Closer{file}
+
+	var r io.Reader = file
+	name := path
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("could not read %s: %w", path, err)
+		}
+		closer = append(closer, gz)
+		r = gz
+		name = strings.TrimSuffix(name, ".gz")
+	}
+
+	comma := ','
+	if strings.HasSuffix(name, ".tsv") {
+		comma = '\t'
+	}
+	c, err := newCSVFile(r, comma)
+	if err != nil {
+		closeAll(closer)
+		return nil, fmt.Errorf("could not read header of %s: %w", path, err)
+	}
+	c.closer = closer
+	return c, nil
+}
+
+// newCSVFile reads the header of r, dropping a leading byte order mark.
+func newCSVFile(r io.Reader, comma rune) (*csvFile, error) {
+	c := &csvFile{Reader: csv.NewReader(r)}
+	c.FieldsPerRecord = -1
+	c.Comma = comma
+
+	var err error
+	if c.Header, err = c.Read(); err != nil {
+		return nil, err
+	}
+	c.column = make(map[string]int, len(c.Header))
+	for i, h := range c.Header {
+		h = strings.TrimPrefix(h, utf8BOM)
+		c.Header[i] = h
+		c.column[h] = i
+	}
+	return c, nil
+}
+
+// Has reports whether the file has the named column.
+func (c *csvFile) Has(name string) bool {
+	_, ok := c.column[name]
+	return ok
+}
+
+// Field returns the named column of record, or "" when there is none.
+func (c *csvFile) Field(record []string, name string) string {
+	if i, ok := c.column[name]; ok && i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+func (c *csvFile) Close() error {
+	return closeAll(c.closer)
+}
+
+// closeAll closes closers in reverse order and returns the first error.
+func closeAll(closers []io.Closer) error {
+	var err error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if cerr := closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Hotel rebuilds a Hotel from a record. Numbers that do not parse are left
+// at zero; listSep is the -list-separator the file was written with.
+func (c *csvFile) Hotel(record []string, listSep string) Hotel {
+	field := func(name string) string { return c.Field(record, name) }
+	integer := func(name string) int64 {
+		v, _ := strconv.ParseInt(field(name), 10, 64)
+		return v
+	}
+	small := func(name string) int {
+		v, _ := strconv.Atoi(field(name))
+		return v
+	}
+	number := func(name string) float64 {
+		v, _ := strconv.ParseFloat(field(name), 64)
+		return v
+	}
+	optional := func(name string) *bool {
+		v, err := strconv.ParseBool(field(name))
+		if err != nil {
+			return nil
+		}
+		return &v
+	}
+
+	hotel := Hotel{
+		Name: field("Name"), Price: field("Price"), CheckIn: field("CheckIn"), CheckOut: field("CheckOut"),
+		Rating: field("Rating"), NumReviews: field("NumReviews"), Address: field("Address"),
+		Amenities: field("Amenities"), RoomType: field("RoomType"), Cancellation: field("Cancellation"),
+		Distance: field("Distance"), PropertyType: field("PropertyType"), StarRating: field("StarRating"),
+		BookingURL: field("BookingURL"), Photos: field("Photos"), GuestScoreBreak: field("GuestScoreBreak"),
+		Description: field("Description"), Adults: small("Adults"), Children: small("Children"),
+		Rooms: small("Rooms"), Currency: field("Currency"), HouseRules: field("HouseRules"),
+		Language: field("Language"), PriceNumeric: number("PriceNumeric"), StarRatingInt: small("StarRatingInt"),
+		DistanceMeters: int(math.Round(number("DistanceMeters"))), ScrapedAt: field("ScrapedAt"), RunID: field("RunID"),
+		SearchCity: field("SearchCity"), ExtractionMs: integer("ExtractionMs"),
+		RatingValue: number("RatingValue"), ReviewCount: small("ReviewCount"), HasReviews: field("HasReviews") == "true",
+		StarRatingType:   field("StarRatingType"),
+		PropertyID:       field("PropertyID"),
+		CanonicalURL:     field("CanonicalURL"),
+		Latitude:         number("Latitude"),
+		Longitude:        number("Longitude"),
+		FreeCancellation: optional("FreeCancellation"),
+		NoPrepayment:     optional("NoPrepayment"),
+		PriceCurrency:    field("PriceCurrency"),
+	}
+
+	// CSVWriter joined the lists with listSep; put back the ", " the
+	// scraper joins them with.
+	unjoin := func(s string) (string, []string) {
+		if s == "" || s == "N/A" || listSep == "" {
+			return s, nil
+		}
+		list := strings.Split(s, listSep)
+		return strings.Join(list, ", "), list
+	}
+	hotel.Amenities, hotel.AmenitiesList = unjoin(hotel.Amenities)
+	hotel.Photos, hotel.PhotosList = unjoin(hotel.Photos)
+	return hotel
+}
+
+// defaultListSep is the default -list-separator.
+const defaultListSep = "|"
+
+// LoadFromCSV reads comma-separated output of CSVWriter back into Hotels,
+// so results can be post-processed without scraping again. Columns are
+// matched by header name, so files with fewer or extra columns load too.
+// Amenities and Photos are split on the default -list-separator; use
+// LoadFromCSVSep for files written with another one.
+func LoadFromCSV(r io.Reader) (Hotels, error) {
+	return LoadFromCSVSep(r, defaultListSep)
+}
+
+// LoadFromCSVSep is LoadFromCSV for files whose Amenities and Photos were
+// joined with listSep.
+func LoadFromCSVSep(r io.Reader, listSep string) (Hotels, error) {
+	file, err := newCSVFile(r, ',')
+	if err != nil {
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+	return file.Hotels(listSep)
+}
+
+// LoadCSVFile is LoadFromCSV for a file on disk, which may also be gzipped
+// or tab-separated.
+func LoadCSVFile(path, listSep string) (Hotels, error) {
+	file, err := openCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hotels, err := file.Hotels(listSep)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return hotels, nil
+}
+
+// Hotels reads the remaining records.
+func (c *csvFile) Hotels(listSep string) (Hotels, error) {
+	var hotels Hotels
+	for {
+		record, err := c.Read()
+		if err == io.EOF {
+			return hotels, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		hotels = append(hotels, c.Hotel(record, listSep))
+	}
+}
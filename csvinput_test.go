@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromCSVRoundTrip(t *testing.T) {
+	yes, no := true, false
+	hotels := Hotels{
+		{
+			Name: "Hotel Adlon, Kempinski", Price: "€ 320", CheckIn: "2026-11-01", CheckOut: "2026-11-03",
+			Rating: "9.1", NumReviews: "2,345 reviews", Address: "Unter den Linden 77, Berlin",
+			Amenities:     "Parking: Free, on-site, WiFi",
+			AmenitiesList: []string{"Parking: Free, on-site", "WiFi"},
+			Photos:        "https://example.com/a.jpg, https://example.com/b.jpg",
+			PhotosList:    []string{"https://example.com/a.jpg", "https://example.com/b.jpg"},
+			RoomType:      "Deluxe \"King\" Room", Cancellation: "Free cancellation",
+			Distance: "1.2 km from centre", BookingURL: "https://www.booking.com/hotel/de/adlon.html",
+			Description: "Line one\nline two", Adults: 2, Children: 1, Rooms: 1, Currency: "EUR",
+			Language: "en-gb", PriceNumeric: 320, StarRatingInt: 5, DistanceMeters: 1200,
+			ScrapedAt: "2026-10-16T09:00:00Z", RunID: "run1", SearchCity: "Berlin", ExtractionMs: 42,
+			RatingValue: 9.1, ReviewCount: 2345, HasReviews: true, StarRatingType: "stars",
+			PropertyID: "de/adlon", CanonicalURL: "https://www.booking.com/hotel/de/adlon.html",
+			Latitude: 52.5163, Longitude: 13.3806, FreeCancellation: &yes, NoPrepayment: &no,
+			PriceCurrency: "EUR",
+		},
+		{
+			Name: "Hostel", Price: "N/A", Amenities: "N/A", Photos: "N/A",
+		},
+	}
+
+	for _, sep := range []string{defaultListSep, ";"} {
+		t.Run(sep, func(t *testing.T) {
+			var buf strings.Builder
+			if err := (CSVWriter{ListSep: sep}).Write(&buf, hotels); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			var got Hotels
+			var err error
+			if sep == defaultListSep {
+				got, err = LoadFromCSV(strings.NewReader(buf.String()))
+			} else {
+				got, err = LoadFromCSVSep(strings.NewReader(buf.String()), sep)
+			}
+			if err != nil {
+				t.Fatalf("LoadFromCSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, hotels) {
+				t.Errorf("round trip mismatch\n got: %+v\nwant: %+v", got, hotels)
+			}
+		})
+	}
+}
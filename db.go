@@ -0,0 +1,288 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Store persists scraped hotels somewhere other than flat files.
+type Store interface {
+	Save(hotels []Hotel) error
+	Close() error
+}
+
+// HotelSink receives each finished job's hotels where a HotelWriter cannot
+// put them: a database, or a file every city shares. Save returns where the
+// hotels went, for the log and the run summary. Concurrent jobs share a
+// sink, so Save must be safe for concurrent use.
+type HotelSink interface {
+	Name() string
+	Save(city string, hotels []Hotel) (string, error)
+}
+
+// SQLiteSink saves each job's hotels to the SQLite database at Path.
+type SQLiteSink struct {
+	Path string
+}
+
+func (SQLiteSink) Name() string { return "SQLite" }
+
+func (s SQLiteSink) Save(city string, hotels []Hotel) (string, error) {
+	if err := saveToDB(s.Path, city, hotels); err != nil {
+		return "", err
+	}
+	return s.Path, nil
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS hotels (
+	id                INTEGER PRIMARY KEY AUTOINCREMENT,
+	city              TEXT NOT NULL,
+	scraped_at        TEXT NOT NULL,
+	property_key      TEXT,
+	scrape_date       TEXT,
+	name              TEXT,
+	price             TEXT,
+	check_in          TEXT,
+	check_out         TEXT,
+	rating            TEXT,
+	num_reviews       TEXT,
+	address           TEXT,
+	amenities         TEXT,
+	room_type         TEXT,
+	cancellation      TEXT,
+	distance          TEXT,
+	property_type     TEXT,
+	star_rating       TEXT,
+	booking_url       TEXT,
+	photos            TEXT,
+	guest_score_break TEXT,
+	description       TEXT,
+	adults            INTEGER,
+	children          INTEGER,
+	rooms             INTEGER,
+	currency          TEXT,
+	house_rules       TEXT,
+	language          TEXT,
+	price_numeric     REAL,
+	star_rating_int   INTEGER,
+	run_id            TEXT,
+	search_city       TEXT,
+	distance_meters   INTEGER,
+	extraction_ms     INTEGER,
+	rating_value      REAL,
+	review_count      INTEGER,
+	has_reviews       INTEGER,
+	star_rating_type  TEXT,
+	property_id       TEXT,
+	canonical_url     TEXT,
+	latitude          REAL,
+	longitude         REAL,
+	free_cancellation INTEGER,
+	no_prepayment     INTEGER,
+	price_currency    TEXT
+)`
+
+// sqliteUpsertIndex makes a property appear once per check-in date and
+// scrape day, so daily re-runs update rows instead of piling up duplicates.
+const sqliteUpsertIndex = `
+CREATE UNIQUE INDEX IF NOT EXISTS hotels_property_stay
+	ON hotels (property_key, check_in, scrape_date)`
+
+// sqliteMigrations adds columns introduced after the first schema to
+// databases created by older versions.
+var sqliteMigrations = map[string]string{
+	"property_key":      "ALTER TABLE hotels ADD COLUMN property_key TEXT",
+	"scrape_date":       "ALTER TABLE hotels ADD COLUMN scrape_date TEXT",
+	"price_numeric":     "ALTER TABLE hotels ADD COLUMN price_numeric REAL",
+	"star_rating_int":   "ALTER TABLE hotels ADD COLUMN star_rating_int INTEGER",
+	"run_id":            "ALTER TABLE hotels ADD COLUMN run_id TEXT",
+	"search_city":       "ALTER TABLE hotels ADD COLUMN search_city TEXT",
+	"distance_meters":   "ALTER TABLE hotels ADD COLUMN distance_meters INTEGER",
+	"extraction_ms":     "ALTER TABLE hotels ADD COLUMN extraction_ms INTEGER",
+	"rating_value":      "ALTER TABLE hotels ADD COLUMN rating_value REAL",
+	"review_count":      "ALTER TABLE hotels ADD COLUMN review_count INTEGER",
+	"has_reviews":       "ALTER TABLE hotels ADD COLUMN has_reviews INTEGER",
+	"star_rating_type":  "ALTER TABLE hotels ADD COLUMN star_rating_type TEXT",
+	"property_id":       "ALTER TABLE hotels ADD COLUMN property_id TEXT",
+	"canonical_url":     "ALTER TABLE hotels ADD COLUMN canonical_url TEXT",
+	"latitude":          "ALTER TABLE hotels ADD COLUMN latitude REAL",
+	"longitude":         "ALTER TABLE hotels ADD COLUMN longitude REAL",
+	"free_cancellation": "ALTER TABLE hotels ADD COLUMN free_cancellation INTEGER",
+	"no_prepayment":     "ALTER TABLE hotels ADD COLUMN no_prepayment INTEGER",
+	"price_currency":    "ALTER TABLE hotels ADD COLUMN price_currency TEXT",
+}
+
+const sqliteInsert = `
+INSERT INTO hotels (
+	city, scraped_at, property_key, scrape_date, name, price, check_in, check_out,
+	rating, num_reviews, address, amenities, room_type, cancellation, distance,
+	property_type, star_rating, booking_url, photos, guest_score_break, description,
+	adults, children, rooms, currency, house_rules, language, price_numeric,
+	star_rating_int, run_id, search_city, distance_meters, extraction_ms,
+	rating_value, review_count, has_reviews, star_rating_type, property_id, canonical_url, latitude, longitude,
+	free_cancellation, no_prepayment, price_currency
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (property_key, check_in, scrape_date) DO UPDATE SET
+	city = excluded.city, scraped_at = excluded.scraped_at, name = excluded.name,
+	price = excluded.price, check_out = excluded.check_out, rating = excluded.rating,
+	num_reviews = excluded.num_reviews, address = excluded.address,
+	amenities = excluded.amenities, room_type = excluded.room_type,
+	cancellation = excluded.cancellation, distance = excluded.distance,
+	property_type = excluded.property_type, star_rating = excluded.star_rating,
+	booking_url = excluded.booking_url, photos = excluded.photos,
+	guest_score_break = excluded.guest_score_break, description = excluded.description,
+	adults = excluded.adults, children = excluded.children, rooms = excluded.rooms,
+	currency = excluded.currency, house_rules = excluded.house_rules,
+	language = excluded.language, price_numeric = excluded.price_numeric,
+	star_rating_int = excluded.star_rating_int, run_id = excluded.run_id,
+	search_city = excluded.search_city, distance_meters = excluded.distance_meters,
+	extraction_ms = excluded.extraction_ms, rating_value = excluded.rating_value,
+	review_count = excluded.review_count, has_reviews = excluded.has_reviews,
+	star_rating_type = excluded.star_rating_type,
+	property_id = excluded.property_id,
+	canonical_url = excluded.canonical_url,
+	latitude = excluded.latitude,
+	longitude = excluded.longitude, free_cancellation = excluded.free_cancellation,
+	no_prepayment = excluded.no_prepayment, price_currency = excluded.price_currency`
+
+// SQLiteStore writes the hotels of a single city to a SQLite database file.
+// Several stores may point at the same file; the busy timeout lets their
+// writes queue up instead of failing.
+type SQLiteStore struct {
+	db   *sql.DB
+	city string
+}
+
+func NewSQLiteStore(path, city string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteUpsertIndex); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create upsert index: %w", err)
+	}
+
+	return &SQLiteStore{db: db, city: city}, nil
+}
+
+// migrateSQLite adds any column from sqliteMigrations the table lacks.
+func migrateSQLite(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(hotels)")
+	if err != nil {
+		return fmt.Errorf("could not inspect schema: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return fmt.Errorf("could not inspect schema: %w", err)
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not inspect schema: %w", err)
+	}
+	rows.Close()
+
+	for column, stmt := range sqliteMigrations {
+		if existing[column] {
+			continue
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("could not add column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// propertyKey identifies a property across runs: the path of its booking
+// URL, which is stable while the query string carries per-search state. Cards
+// without a URL fall back to name and address.
+func propertyKey(hotel Hotel) string {
+	if u, err := url.Parse(hotel.BookingURL); err == nil && u.Path != "" && hotel.BookingURL != "N/A" {
+		return strings.TrimSuffix(u.Path, "/")
+	}
+	return hotel.Name + "|" + hotel.Address
+}
+
+// nullCoordinate stores Latitude or Longitude as NULL when the hotel has
+// no coordinates.
+func nullCoordinate(hotel Hotel, v float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v, Valid: hotel.HasCoordinates()}
+}
+
+// Save upserts hotels in one transaction, keyed by property, check-in date
+// and the day of the scrape.
+func (s *SQLiteStore) Save(hotels []Hotel) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(sqliteInsert)
+	if err != nil {
+		return fmt.Errorf("could not prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	scrapedAt, scrapeDate := now.Format(time.RFC3339), now.Format("2006-01-02")
+	for _, hotel := range hotels {
+		if _, err := stmt.Exec(
+			s.city, scrapedAt, propertyKey(hotel), scrapeDate, hotel.Name, hotel.Price, hotel.CheckIn,
+			hotel.CheckOut, hotel.Rating, hotel.NumReviews, hotel.Address, hotel.Amenities, hotel.RoomType, hotel.Cancellation,
+			hotel.Distance, hotel.PropertyType, hotel.StarRating, hotel.BookingURL, hotel.Photos,
+			hotel.GuestScoreBreak, hotel.Description, hotel.Adults, hotel.Children, hotel.Rooms,
+			hotel.Currency, hotel.HouseRules, hotel.Language, hotel.PriceNumeric,
+			hotel.StarRatingInt, hotel.RunID, hotel.SearchCity, hotel.DistanceMeters, hotel.ExtractionMs,
+			hotel.RatingValue, hotel.ReviewCount, hotel.HasReviews, hotel.StarRatingType, hotel.PropertyID,
+			hotel.CanonicalURL, nullCoordinate(hotel, hotel.Latitude), nullCoordinate(hotel, hotel.Longitude),
+			hotel.FreeCancellation, hotel.NoPrepayment, hotel.PriceCurrency,
+		); err != nil {
+			return fmt.Errorf("error upserting %q: %w", hotel.Name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// saveToDB opens a store for city, saves hotels and closes it again.
+func saveToDB(path, city string, hotels []Hotel) error {
+	store, err := NewSQLiteStore(path, city)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(hotels); err != nil {
+		store.Close()
+		return err
+	}
+	return store.Close()
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"golang.org/x/time/rate"
+)
+
+// extractDetailPage opens a hotel's own page and reads the information that
+// the search results only show in part. Only the detail fields are set on
+// the returned Hotel; use mergeDetails to combine it with a search result.
+func extractDetailPage(ctx context.Context, page playwright.Page, url string, sel Selectors, limiter *rate.Limiter) (Hotel, error) {
+	if strings.HasPrefix(url, "/") {
+		url = "https://www.booking.com" + url
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		return Hotel{}, err
+	}
+	if _, err := page.Goto(url, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+		Timeout:   playwright.Float(remainingMs(ctx, 30000)),
+	}); err != nil {
+		return Hotel{}, fmt.Errorf("could not open detail page: %w", err)
+	}
+
+	getTextContent := func(selector string) string {
+		element, err := page.QuerySelector(selector)
+		if err != nil || element == nil {
+			debugf("detail page %s: no match for %s", url, selector)
+			return ""
+		}
+		text, err := element.TextContent()
+		if err != nil {
+			return ""
+		}
+		return strings.Join(strings.Fields(text), " ")
+	}
+
+	var detail Hotel
+	detail.Description = getTextContent(sel.DetailDescription)
+	detail.Cancellation = getTextContent(sel.DetailCancellation)
+	detail.HouseRules = getTextContent(sel.DetailHouseRules)
+
+	// Facilities are grouped by category ("Parking", "Internet", ...); keep
+	// the category so the flattened list stays readable.
+	groups, err := page.QuerySelectorAll(sel.DetailFacilityGroup)
+	if err == nil {
+		var categories []string
+		for _, group := range groups {
+			title := ""
+			if heading, err := group.QuerySelector("h3"); err == nil && heading != nil {
+				title, _ = heading.TextContent()
+				title = strings.TrimSpace(title)
+			}
+
+			items, err := group.QuerySelectorAll("li")
+			if err != nil {
+				continue
+			}
+			var names []string
+			for _, item := range items {
+				text, _ := item.TextContent()
+				if text = strings.Join(strings.Fields(text), " "); text != "" {
+					names = append(names, text)
+					detail.AmenitiesList = append(detail.AmenitiesList, text)
+				}
+			}
+			if len(names) == 0 {
+				continue
+			}
+			if title != "" {
+				categories = append(categories, title+": "+strings.Join(names, ", "))
+			} else {
+				categories = append(categories, strings.Join(names, ", "))
+			}
+		}
+		detail.Amenities = strings.Join(categories, "; ")
+	}
+
+	return detail, nil
+}
+
+// mergeDetails copies every detail field that was found over the values
+// taken from the search results card.
+func mergeDetails(hotel *Hotel, detail Hotel) {
+	if detail.Description != "" {
+		hotel.Description = detail.Description
+	}
+	if detail.Amenities != "" {
+		hotel.Amenities = detail.Amenities
+		hotel.AmenitiesList = detail.AmenitiesList
+	}
+	if detail.Cancellation != "" {
+		hotel.Cancellation = detail.Cancellation
+	}
+	if detail.HouseRules != "" {
+		hotel.HouseRules = detail.HouseRules
+	}
+}
+
+// fetchDetailsParallel visits the detail pages of hotels with up to workers
+// pages at once, each in its own browser context, and returns a copy with
+// the details merged in by index. Navigations still go through
+// cfg.NavLimiter. Every cfg.ContextRotation pages a worker swaps its context
+// for a fresh one with a new user agent, so cookies do not pile up into a
+// fingerprint. A page that fails is logged and skipped; the error is only
+// set when ctx ends, in which case the copy holds what was fetched so far.
+func fetchDetailsParallel(ctx context.Context, browser playwright.Browser, hotels []Hotel, workers int, cfg Config, rng *rand.Rand, logger *slog.Logger) ([]Hotel, error) {
+	merged := make([]Hotel, len(hotels))
+	copy(merged, hotels)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for w := 0; w < min(workers, len(hotels)); w++ {
+		page, err := newBrowserPage(browser, cfg, "")
+		if err != nil {
+			if w == 0 {
+				return merged, err
+			}
+			logger.Warn("Could not open detail page worker", "err", err)
+			break
+		}
+
+		// rand.Rand is not safe for concurrent use; each worker gets its
+		// own, seeded from rng so -seed runs stay reproducible.
+		workerRNG := rand.New(rand.NewSource(rng.Int63()))
+
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			// page is replaced on rotation, so close whichever is current.
+			defer func() { page.Context().Close() }()
+
+			navigations := 0
+			for i := range jobs {
+				if cfg.ContextRotation > 0 && navigations == cfg.ContextRotation {
+					rotated, err := rotateContext(browser, page, cfg, workerRNG, "")
+					if err != nil {
+						logger.Warn("Could not rotate browser context; keeping the old one", "worker", worker, "err", err)
+					} else {
+						page = rotated
+						logger.Info("Rotated browser context", "worker", worker, "after_pages", navigations)
+					}
+					navigations = 0
+				}
+				navigations++
+
+				// Each index is owned by exactly one worker, so writing
+				// merged[i] needs no lock.
+				detail, err := extractDetailPage(ctx, page, hotels[i].BookingURL, cfg.Selectors, cfg.NavLimiter)
+				if err != nil {
+					if ctx.Err() != nil {
+						mu.Lock()
+						if firstErr == nil {
+							firstErr = ctx.Err()
+						}
+						mu.Unlock()
+						continue
+					}
+					logger.Warn("Could not fetch details", "hotel", hotels[i].Name, "err", err)
+					continue
+				}
+				mergeDetails(&merged[i], detail)
+			}
+		}(w)
+	}
+
+feed:
+	for i := range hotels {
+		if hotels[i].BookingURL == "" {
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return merged, firstErr
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// diffRow is one property and check-in date as read back from an output
+// file. Amount is 0 when the price could not be parsed.
+type diffRow struct {
+	Name       string
+	CheckIn    string
+	BookingURL string
+	Price      string
+	Amount     float64
+}
+
+// runDiff implements "diff [-o file] OLD NEW": it compares two runs, each
+// given as a directory of output CSVs or a single (combined) CSV, and writes
+// price changes plus new and disappeared properties as CSV.
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	output := flags.String("o", "-", "Write the diff CSV to this file; - means stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [-o file] OLD NEW\n\nOLD and NEW are run directories or CSV files.\n\n", filepath.Base(os.Args[0]))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected two runs to compare, got %d", flags.NArg())
+	}
+
+	oldRows, err := readDiffRows(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	newRows, err := readDiffRows(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	summaryOut := io.Writer(os.Stdout)
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("could not create %s: %w", *output, err)
+		}
+		defer file.Close()
+		w = file
+	} else {
+		// Keep stdout clean for the CSV.
+		summaryOut = os.Stderr
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Status", "Name", "CheckIn", "BookingURL", "OldPrice", "NewPrice", "Change", "ChangePercent"})
+
+	keys := make([]string, 0, len(oldRows)+len(newRows))
+	for key := range oldRows {
+		keys = append(keys, key)
+	}
+	for key := range newRows {
+		if _, ok := oldRows[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	matched, added, gone, compared := 0, 0, 0, 0
+	totalPercent := 0.0
+	for _, key := range keys {
+		before, hadBefore := oldRows[key]
+		after, hasAfter := newRows[key]
+		switch {
+		case hadBefore && hasAfter:
+			matched++
+			change, percent := "", ""
+			if before.Amount > 0 && after.Amount > 0 {
+				delta := after.Amount - before.Amount
+				pct := 100 * delta / before.Amount
+				change = strconv.FormatFloat(delta, 'f', 2, 64)
+				percent = strconv.FormatFloat(pct, 'f', 1, 64)
+				totalPercent += pct
+				compared++
+			}
+			writer.Write([]string{"matched", after.Name, after.CheckIn, after.BookingURL, before.Price, after.Price, change, percent})
+		case hasAfter:
+			added++
+			writer.Write([]string{"new", after.Name, after.CheckIn, after.BookingURL, "", after.Price, "", ""})
+		default:
+			gone++
+			writer.Write([]string{"gone", before.Name, before.CheckIn, before.BookingURL, before.Price, "", "", ""})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing diff: %w", err)
+	}
+
+	avg := "n/a"
+	if compared > 0 {
+		avg = fmt.Sprintf("%+.1f%%", totalPercent/float64(compared))
+	}
+	fmt.Fprintf(summaryOut, "%d matched, avg change %s, %d new, %d gone\n", matched, avg, added, gone)
+	return nil
+}
+
+// readDiffRows reads every output CSV under path, or path itself when it is
+// a file, keyed by normalized booking URL and check-in date. Files are read
+// in name order, so with several runs in one directory the latest wins.
+func readDiffRows(path string) (map[string]diffRow, error) {
+	files, err := listCSVFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no CSV files in %s", path)
+	}
+
+	rows := make(map[string]diffRow)
+	for _, file := range files {
+		if err := readDiffFile(file, rows); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// readDiffFile adds the rows of one CSV to rows. Columns are found by
+// header name, so files with -columns or a leading City column work as long
+// as Name, Price and BookingURL are present.
+func readDiffFile(path string, rows map[string]diffRow) error {
+	file, err := openCSV(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, required := range []string{"Name", "Price", "BookingURL"} {
+		if !file.Has(required) {
+			return fmt.Errorf("%s has no %s column", path, required)
+		}
+	}
+
+	for {
+		record, err := file.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", path, err)
+		}
+		row := diffRow{
+			Name:       file.Field(record, "Name"),
+			CheckIn:    file.Field(record, "CheckIn"),
+			BookingURL: file.Field(record, "BookingURL"),
+			Price:      file.Field(record, "Price"),
+		}
+		if row.Price != "" && row.Price != "N/A" {
+			row.Amount, _, _ = parsePrice(row.Price, file.Field(record, "Language"))
+		}
+		key := propertyKey(Hotel{Name: row.Name, Address: file.Field(record, "Address"), BookingURL: row.BookingURL}) + "|" + row.CheckIn
+		rows[key] = row
+	}
+}
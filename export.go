@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/spf13/cobra"
+)
+
+// saveSnapshot writes the page's HTML next to the output files, in the
+// default naming layout so the export command can tell the city and stay
+// from the name.
+func saveSnapshot(page playwright.Page, dir, city string, checkIn, checkOut time.Time) (string, error) {
+	html, err := page.Content()
+	if err != nil {
+		return "", fmt.Errorf("could not read page HTML: %w", err)
+	}
+	path, err := makeOutputDir(fileNamerDefault.Path(dir, city, checkIn, checkOut, "html"))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
+		return "", fmt.Errorf("could not write HTML snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// parseSnapshotName recovers the city and stay from a snapshot named by
+// saveSnapshot.
+func parseSnapshotName(path string) (city string, checkIn, checkOut time.Time, err error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	prefix, rest, ok := strings.Cut(name, "_hotels_")
+	parts := strings.Split(rest, "_")
+	if !ok || len(parts) < 2 {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%s is not named <city>_hotels_<checkin>_<checkout>_<time>.html", path)
+	}
+	if checkIn, err = time.Parse("2006-01-02", parts[0]); err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%s: invalid check-in date: %w", path, err)
+	}
+	if checkOut, err = time.Parse("2006-01-02", parts[1]); err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%s: invalid check-out date: %w", path, err)
+	}
+	return strings.ReplaceAll(prefix, "_", " "), checkIn, checkOut, nil
+}
+
+func newExportCommand() *cobra.Command {
+	var format, outputDir, listSep string
+	cmd := &cobra.Command{
+		Use:   "export SNAPSHOT...",
+		Short: "Re-extract hotels from saved HTML snapshots",
+		Long: `Export loads results pages saved with "scrape -save-html" into a headless
+browser and runs the extraction on them again, writing the hotels like a
+scrape would. The city and stay dates are taken from each snapshot's file
+name. Use it after fixing a selector to recover data without scraping again.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			writers, err := parseFormats(format, CSVWriter{Comma: ',', ListSep: listSep}, nil)
+			if err != nil {
+				return fmt.Errorf("invalid --format: %w", err)
+			}
+			return runExport(args, writers, outputDir)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "both", "Output formats, as for scrape -format")
+	cmd.Flags().StringVar(&outputDir, "output-dir", envOr("BOOKING_OUTPUT_DIR", "data"), "Directory to write the exported files to (env BOOKING_OUTPUT_DIR)")
+	cmd.Flags().StringVar(&listSep, "list-separator", defaultListSep, "Separator for the Amenities and Photos lists in CSV output")
+	return cmd
+}
+
+func runExport(snapshots []string, writers []HotelWriter, outputDir string) error {
+	pw, err := playwright.Run()
+	if err != nil {
+		return fmt.Errorf("could not start playwright: %v", err)
+	}
+	defer pw.Stop()
+
+	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{Headless: playwright.Bool(true)})
+	if err != nil {
+		return fmt.Errorf("could not launch browser: %v", err)
+	}
+	defer browser.Close()
+	page, err := browser.NewPage()
+	if err != nil {
+		return fmt.Errorf("could not create page: %v", err)
+	}
+
+	runID := newRunID()
+	for _, snapshot := range snapshots {
+		city, checkIn, checkOut, err := parseSnapshotName(snapshot)
+		if err != nil {
+			return err
+		}
+		html, err := os.ReadFile(snapshot)
+		if err != nil {
+			return err
+		}
+		if err := page.SetContent(string(html)); err != nil {
+			return fmt.Errorf("could not load %s: %w", snapshot, err)
+		}
+
+		cfg := Config{Selectors: defaultSelectors, CheckIn: checkIn, CheckOut: checkOut, RunID: runID}
+		hotels, err := extractHotelData(page, city, cfg, func(Hotel) error { return nil })
+		if err != nil {
+			return fmt.Errorf("extracting hotels from %s failed: %w", snapshot, err)
+		}
+		hotels = hotels.Unique()
+
+		for _, w := range writers {
+			filePath, err := exportHotels(w, hotels, outputDir, city, checkIn, checkOut, false)
+			if err != nil {
+				return err
+			}
+			infof("Exported %d hotels from %s to %s", len(hotels), snapshot, filePath)
+		}
+	}
+	return nil
+}
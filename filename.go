@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultFilenameTemplate is the name outputs had before -filename-template.
+const defaultFilenameTemplate = "{{.City}}_hotels_{{.CheckIn}}_{{.CheckOut}}_{{.Time}}"
+
+// filenameData holds the variables available to -filename-template.
+type filenameData struct {
+	City     string
+	Date     string
+	Time     string
+	RunID    string
+	CheckIn  string
+	CheckOut string
+}
+
+// FileNamer names output files and screenshots from a template. The
+// extension is added by the caller, and output files still go into a dated
+// directory.
+type FileNamer struct {
+	tmpl   *template.Template
+	runID  string
+	custom bool
+}
+
+var (
+	// fileNamerDefault always uses the default layout. HTML snapshots are
+	// named with it because the export command reads the city and stay back
+	// from the name.
+	fileNamerDefault = mustFileNamer(defaultFilenameTemplate, "")
+	// fileNamer is replaced at startup when -filename-template is set.
+	fileNamer = fileNamerDefault
+)
+
+// NewFileNamer parses text and renders it with sample values, so a template
+// that refers to unknown variables or yields an unusable name is rejected
+// before any city is scraped. So is one that gives two cities, or two
+// check-in dates, the same name: their jobs run at the same time and would
+// overwrite each other's outputs and .partial streams.
+func NewFileNamer(text, runID string) (*FileNamer, error) {
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	n := &FileNamer{tmpl: tmpl, runID: runID, custom: text != defaultFilenameTemplate}
+
+	now := time.Now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	name, err := n.render("Sample City", day, day.AddDate(0, 0, 1), now)
+	if err != nil {
+		return nil, err
+	}
+	if other, _ := n.render("Other City", day, day.AddDate(0, 0, 1), now); other == name {
+		return nil, errors.New("template must include {{.City}} so cities get their own files")
+	}
+	if other, _ := n.render("Sample City", day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), now); other == name {
+		return nil, errors.New("template must include {{.CheckIn}} so stays get their own files")
+	}
+	return n, nil
+}
+
+// CheckUnique reports two jobs of the run whose outputs would get the same
+// name, such as stays that share a check-in date when the template leaves
+// out {{.CheckOut}}.
+func (n *FileNamer) CheckUnique(cities []string, stays []Stay) error {
+	now := time.Now()
+	seen := make(map[string]string)
+	for _, city := range cities {
+		for _, stay := range stays {
+			name, err := n.render(city, stay.CheckIn, stay.CheckOut, now)
+			if err != nil {
+				// Path falls back to the default layout for this job.
+				continue
+			}
+			job := city + " " + stay.String()
+			if other, ok := seen[name]; ok {
+				return fmt.Errorf("%s and %s would both be written as %q", other, job, name)
+			}
+			seen[name] = job
+		}
+	}
+	return nil
+}
+
+func mustFileNamer(text, runID string) *FileNamer {
+	n, err := NewFileNamer(text, runID)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (n *FileNamer) render(city string, checkIn, checkOut, now time.Time) (string, error) {
+	var buf bytes.Buffer
+	err := n.tmpl.Execute(&buf, filenameData{
+		City:     strings.ReplaceAll(city, " ", "_"),
+		Date:     now.Format("2006-01-02"),
+		Time:     now.Format("15-04-05"),
+		RunID:    n.runID,
+		CheckIn:  checkIn.Format("2006-01-02"),
+		CheckOut: checkOut.Format("2006-01-02"),
+	})
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(buf.String())
+	switch {
+	case name == "":
+		return "", errors.New("template produced an empty file name")
+	case strings.ContainsAny(name, `/\`):
+		return "", fmt.Errorf("file name %q must not contain path separators", name)
+	}
+	return name, nil
+}
+
+// Path returns <dir>/<date>/<name>.<ext> for one job's output.
+func (n *FileNamer) Path(dir, city string, checkIn, checkOut time.Time, ext string) string {
+	now := time.Now()
+	name, err := n.render(city, checkIn, checkOut, now)
+	if err != nil {
+		// The template was checked at startup; this only guards against
+		// a value that slipped through, such as a city with a slash.
+		name, _ = fileNamerDefault.render(strings.NewReplacer("/", "_", `\`, "_").Replace(city), checkIn, checkOut, now)
+	}
+	return filepath.Join(dir, now.Format("2006-01-02"), name+"."+ext)
+}
+
+// Screenshot returns the file name for a screenshot taken at stage. The
+// default layout is <city>_<stage>.png, with spaces in the city replaced by
+// underscores as in output files; a custom template gets _<stage>.png
+// appended. Page and error screenshots both get their names here.
+func (n *FileNamer) Screenshot(city string, checkIn, checkOut time.Time, stage string) string {
+	if n.custom {
+		if name, err := n.render(city, checkIn, checkOut, time.Now()); err == nil {
+			return name + "_" + stage + ".png"
+		}
+	}
+	return fmt.Sprintf("%s_%s.png", strings.ReplaceAll(city, " ", "_"), stage)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewFileNamer(t *testing.T) {
+	tests := []struct {
+		template string
+		ok       bool
+	}{
+		{defaultFilenameTemplate, true},
+		{"{{.City}}_{{.CheckIn}}", true},
+		{"{{.RunID}}/{{.City}}_{{.CheckIn}}", false},
+		{"{{.Date}}_{{.RunID}}", false},
+		{"{{.City}}_{{.Date}}", false},
+		{"{{.CheckIn}}_{{.CheckOut}}", false},
+		{"{{.Hotel}}", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, err := NewFileNamer(tt.template, "run1"); (err == nil) != tt.ok {
+			t.Errorf("NewFileNamer(%q) error = %v; want ok %v", tt.template, err, tt.ok)
+		}
+	}
+}
+
+func TestFileNamerCheckUnique(t *testing.T) {
+	day := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	stays := []Stay{
+		{CheckIn: day, CheckOut: day.AddDate(0, 0, 2)},
+		{CheckIn: day, CheckOut: day.AddDate(0, 0, 3)},
+	}
+	cities := []string{"Austin", "Dallas"}
+
+	n, err := NewFileNamer("{{.City}}_{{.CheckIn}}_{{.CheckOut}}", "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.CheckUnique(cities, stays); err != nil {
+		t.Errorf("CheckUnique with the check-out date: %v", err)
+	}
+
+	// The two stays share a check-in date.
+	n, err = NewFileNamer("{{.City}}_{{.CheckIn}}", "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.CheckUnique(cities, stays); err == nil {
+		t.Error("CheckUnique accepted stays that get the same name")
+	}
+}
+
+func TestFileNamerScreenshot(t *testing.T) {
+	day := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	custom, err := NewFileNamer("{{.City}}_{{.CheckIn}}", "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		namer *FileNamer
+		stage string
+		want  string
+	}{
+		{fileNamerDefault, "after_load", "San_Antonio_after_load.png"},
+		{fileNamerDefault, "error_navigation_10-00-00", "San_Antonio_error_navigation_10-00-00.png"},
+		{custom, "after_load", "San_Antonio_2026-11-01_after_load.png"},
+		{custom, "error_navigation_10-00-00", "San_Antonio_2026-11-01_error_navigation_10-00-00.png"},
+	}
+	for _, tt := range tests {
+		if got := tt.namer.Screenshot("San Antonio", day, day.AddDate(0, 0, 2), tt.stage); got != tt.want {
+			t.Errorf("Screenshot(%q) = %q; want %q", tt.stage, got, tt.want)
+		}
+	}
+}
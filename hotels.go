@@ -0,0 +1,374 @@
+package main
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hotels is a list of scraped hotels with helpers for in-process use. The
+// sort methods return a sorted copy and put hotels with an unknown value
+// last.
+type Hotels []Hotel
+
+// Filter returns the hotels for which keep returns true.
+func (hs Hotels) Filter(keep func(Hotel) bool) Hotels {
+	filtered := make(Hotels, 0, len(hs))
+	for _, hotel := range hs {
+		if keep(hotel) {
+			filtered = append(filtered, hotel)
+		}
+	}
+	return filtered
+}
+
+// SortByPrice orders hotels from cheapest to most expensive.
+func (hs Hotels) SortByPrice() Hotels {
+	return hs.sortBy(func(h Hotel) float64 {
+		if h.PriceNumeric <= 0 {
+			return math.NaN()
+		}
+		return h.PriceNumeric
+	}, false)
+}
+
+// SortByRating orders hotels from best to worst review score.
+func (hs Hotels) SortByRating() Hotels {
+	return hs.sortBy(Hotel.rating, true)
+}
+
+// rating returns RatingValue, or NaN for a property without reviews, whose
+// RatingValue of 0 is not a score.
+func (h Hotel) rating() float64 {
+	if !h.HasReviews {
+		return math.NaN()
+	}
+	return h.RatingValue
+}
+
+// SortByDistance orders hotels from nearest to farthest by DistanceMeters,
+// falling back to parsing Distance for hotels that were built without it.
+func (hs Hotels) SortByDistance() Hotels {
+	return hs.sortBy(func(h Hotel) float64 {
+		if h.DistanceMeters > 0 {
+			return float64(h.DistanceMeters)
+		}
+		return distanceMeters(h.Distance)
+	}, false)
+}
+
+// PriceStats returns the lowest, highest and average PriceNumeric of the
+// hotels with a parsed price, or zeros when there are none.
+func (hs Hotels) PriceStats() (lowest, highest, average float64) {
+	n := 0
+	for _, hotel := range hs {
+		if hotel.PriceNumeric <= 0 {
+			continue
+		}
+		if n == 0 || hotel.PriceNumeric < lowest {
+			lowest = hotel.PriceNumeric
+		}
+		highest = max(highest, hotel.PriceNumeric)
+		average += hotel.PriceNumeric
+		n++
+	}
+	if n > 0 {
+		average /= float64(n)
+	}
+	return lowest, highest, average
+}
+
+// Stats summarizes a batch of hotels. The averages and price bounds only
+// count hotels where the value was parsed and are 0 when none was.
+type Stats struct {
+	Count             int
+	AvgPrice          float64
+	MinPrice          float64
+	MaxPrice          float64
+	AvgRating         float64
+	AvgDistanceMeters float64
+}
+
+// Statistics computes Stats for a quick sanity check after scraping.
+func (hs Hotels) Statistics() Stats {
+	stats := Stats{Count: len(hs)}
+	stats.MinPrice, stats.MaxPrice, stats.AvgPrice = hs.PriceStats()
+
+	ratings, distances := 0, 0
+	for _, hotel := range hs {
+		if hotel.HasReviews {
+			stats.AvgRating += hotel.RatingValue
+			ratings++
+		}
+		if hotel.DistanceMeters >= 0 {
+			stats.AvgDistanceMeters += float64(hotel.DistanceMeters)
+			distances++
+		}
+	}
+	if ratings > 0 {
+		stats.AvgRating /= float64(ratings)
+	}
+	if distances > 0 {
+		stats.AvgDistanceMeters /= float64(distances)
+	}
+	return stats
+}
+
+// ExtractionStats returns the shortest, longest and mean ExtractionMs, or
+// zeros when there are no hotels.
+func (hs Hotels) ExtractionStats() (fastest, slowest int64, mean float64) {
+	for i, hotel := range hs {
+		if i == 0 || hotel.ExtractionMs < fastest {
+			fastest = hotel.ExtractionMs
+		}
+		slowest = max(slowest, hotel.ExtractionMs)
+		mean += float64(hotel.ExtractionMs)
+	}
+	if len(hs) > 0 {
+		mean /= float64(len(hs))
+	}
+	return fastest, slowest, mean
+}
+
+// sortBy stable-sorts a copy by key; NaN keys go last.
+func (hs Hotels) sortBy(key func(Hotel) float64, descending bool) Hotels {
+	sorted := make(Hotels, len(hs))
+	copy(sorted, hs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := key(sorted[i]), key(sorted[j])
+		switch {
+		case math.IsNaN(a):
+			return false
+		case math.IsNaN(b):
+			return true
+		case descending:
+			return a > b
+		default:
+			return a < b
+		}
+	})
+	return sorted
+}
+
+// Unique drops repeated (Name, Address) pairs, keeping the first
+// occurrence. Re-rendered cards otherwise show up twice.
+func (hs Hotels) Unique() Hotels {
+	type key struct{ name, address string }
+	seen := make(map[key]bool, len(hs))
+	unique := make(Hotels, 0, len(hs))
+	for _, hotel := range hs {
+		k := key{hotel.Name, hotel.Address}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		unique = append(unique, hotel)
+	}
+	return unique
+}
+
+// Merge appends other to hs and drops the duplicates with Unique, keeping
+// the hotels of hs. It combines the files of overlapping runs for the same
+// city and stay; like Unique it ignores the dates, so filter by CheckIn
+// first when the files cover different stays.
+func (hs Hotels) Merge(other Hotels) Hotels {
+	merged := make(Hotels, 0, len(hs)+len(other))
+	merged = append(merged, hs...)
+	return append(merged, other...).Unique()
+}
+
+// propertyPath matches the path of a property page, /hotel/<country>/<slug>
+// with an optional language suffix such as ".en-gb" before ".html".
+var propertyPath = regexp.MustCompile(`^/hotel/([a-z]{2})/([^/.]+)(?:\.[a-z]{2}(?:-[a-z]{2})?)?\.html$`)
+
+// parsePropertyID extracts a stable property identifier from a booking
+// URL: "<country>/<slug>" from the path, or the hotel_id query parameter
+// when the path is not a property page. It returns "" when neither is
+// there.
+func parsePropertyID(bookingURL string) string {
+	u, err := url.Parse(bookingURL)
+	if err != nil {
+		return ""
+	}
+	if match := propertyPath.FindStringSubmatch(u.Path); match != nil {
+		return match[1] + "/" + match[2]
+	}
+	return u.Query().Get("hotel_id")
+}
+
+// bookingOrigin resolves relative hrefs when the page has no web address,
+// as with the snapshots the export command loads.
+const bookingOrigin = "https://www.booking.com"
+
+// canonicalParams are the query parameters canonicalURL keeps: the ones
+// that change the stay being shown. The rest is tracking and session state.
+var canonicalParams = []string{"checkin", "checkout", "group_adults", "group_children", "no_rooms", "age"}
+
+// canonicalURL resolves href against the page address base and drops the
+// fragment and every query parameter but canonicalParams. It returns ""
+// when href does not parse.
+func canonicalURL(href, base string) string {
+	origin, err := url.Parse(base)
+	if err != nil || (origin.Scheme != "http" && origin.Scheme != "https") {
+		origin, _ = url.Parse(bookingOrigin)
+	}
+	u, err := origin.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	params, query := u.Query(), url.Values{}
+	for _, name := range canonicalParams {
+		if values, ok := params[name]; ok {
+			query[name] = values
+		}
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawQuery: query.Encode()}).String()
+}
+
+// freeCancellationPhrases and noPrepaymentPhrases are the lower-cased badge
+// texts Booking.com shows in the supported languages.
+var (
+	freeCancellationPhrases = []string{
+		"free cancellation",                                 // en
+		"kostenlose stornierung", "kostenfreie stornierung", // de
+		"annulation gratuite",                        // fr
+		"cancelación gratis", "cancelación gratuita", // es
+		"cancellazione gratuita",                       // it
+		"gratis annuleren",                             // nl
+		"cancelamento grátis", "cancelamento gratuito", // pt
+	}
+	noPrepaymentPhrases = []string{
+		"no prepayment", "pay at the property", // en
+		"keine vorauszahlung", "zahlung in der unterkunft", // de
+		"aucun prépaiement", "pas de prépaiement", "paiement sur place", // fr
+		"sin pago por adelantado", "pago en el alojamiento", // es
+		"nessun pagamento anticipato", "paga in struttura", // it
+		"geen vooruitbetaling", "betaal in de accommodatie", // nl
+		"sem pagamento antecipado", "pagamento no estabelecimento", // pt
+	}
+)
+
+// paymentTerms looks for the free cancellation and no prepayment badges in
+// a card's cancellation and payment texts. Both are nil when the card has
+// neither text, since nothing can be said about the terms then.
+func paymentTerms(texts ...string) (freeCancellation, noPrepayment *bool) {
+	var found []string
+	for _, text := range texts {
+		if text != "" && text != "N/A" {
+			found = append(found, strings.ToLower(text))
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	text := strings.Join(found, " ")
+	contains := func(phrases []string) *bool {
+		v := slices.ContainsFunc(phrases, func(p string) bool { return strings.Contains(text, p) })
+		return &v
+	}
+	return contains(freeCancellationPhrases), contains(noPrepaymentPhrases)
+}
+
+var coordinatePair = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// parseCoordinates reads "lat,lng". Values out of range, and 0,0, are
+// rejected.
+func parseCoordinates(s string) (lat, lng float64, ok bool) {
+	match := coordinatePair.FindStringSubmatch(s)
+	if match == nil {
+		return 0, 0, false
+	}
+	lat, _ = strconv.ParseFloat(match[1], 64)
+	lng, _ = strconv.ParseFloat(match[2], 64)
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 || (lat == 0 && lng == 0) {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+var (
+	ratingNumber = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+	// reviewNumber matches a number whose separators only group thousands,
+	// plus any decimal part, so that a score such as "8.6" next to the count
+	// is matched on its own.
+	reviewNumber = regexp.MustCompile(`(\d{1,3}(?:[.,\s]\d{3})+|\d+)([.,]\d+)?`)
+)
+
+// parseRating reads the score out of review-score text such as
+// "Scored 8.6 8.6" or "8,6", which repeats or localizes the number. It
+// returns 0 when there is no score between 0 and 10.
+func parseRating(s string) float64 {
+	match := ratingNumber.FindString(s)
+	if match == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+	if err != nil || value > 10 {
+		return 0
+	}
+	return value
+}
+
+// parseReviewCount reads a count such as "1,234 reviews" or "1.234
+// Bewertungen". Review counts are whole numbers, so it takes the last number
+// without a decimal part, which skips a score shown before it as in "8.6 ·
+// 1,234 reviews". It returns 0 when there is no count.
+func parseReviewCount(s string) int {
+	var match string
+	for _, m := range reviewNumber.FindAllStringSubmatch(s, -1) {
+		if m[2] == "" {
+			match = m[1]
+		}
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, match)
+	count, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+var (
+	distanceValue = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(kms?|kilometers?|kilometres?|kilómetros?|mi|miles?|meilen|millas?|m|meters?|metres?|metros?|ft|feet|foot|pies)\b`)
+	// cityCentre matches the texts shown instead of a distance for
+	// properties in the centre itself.
+	cityCentre = regexp.MustCompile(`(?i)\bin (the )?(city )?cent(er|re)\b|\bim (stadt)?zentrum\b|\ben el centro\b`)
+)
+
+// distanceMeters converts texts such as "1.2 km from downtown", "0.8 miles
+// from center" or "1,2 km vom Zentrum" to meters. Distances from the centre
+// never need a thousands separator, so the one separator a distance may have
+// is a decimal point: "1.234 km" is 1234 m. A property in the city centre is
+// 0; NaN means there is no distance in s.
+func distanceMeters(s string) float64 {
+	match := distanceValue.FindStringSubmatch(s)
+	if match == nil {
+		if cityCentre.MatchString(s) {
+			return 0
+		}
+		return math.NaN()
+	}
+	value, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	switch unit := strings.ToLower(match[2]); {
+	case strings.HasPrefix(unit, "km") || strings.HasPrefix(unit, "kil"):
+		return value * 1000
+	case unit == "mi" || strings.HasPrefix(unit, "mil") || unit == "meilen":
+		return value * 1609.344
+	case unit == "ft" || unit == "feet" || unit == "foot" || unit == "pies":
+		return value * 0.3048
+	}
+	return value
+}
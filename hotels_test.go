@@ -0,0 +1,340 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestPaymentTerms(t *testing.T) {
+	tests := []struct {
+		name                           string
+		cancellation, payment          string
+		freeCancellation, noPrepayment bool
+	}{
+		{"en", "Free cancellation", "No prepayment needed – pay at the property", true, true},
+		{"en paid", "Non-refundable", "Pay in advance", false, false},
+		{"de", "Kostenlose Stornierung", "Keine Vorauszahlung nötig", true, true},
+		{"fr", "Annulation gratuite", "Aucun prépaiement requis", true, true},
+		{"es", "Cancelación gratis", "Sin pago por adelantado", true, true},
+		{"it", "Cancellazione gratuita", "Nessun pagamento anticipato", true, true},
+		{"nl", "Gratis annuleren", "Geen vooruitbetaling nodig", true, true},
+		{"pt", "Cancelamento grátis", "Sem pagamento antecipado", true, true},
+		{"one text", "Free cancellation", "N/A", true, false},
+		{"other text", "", "Pay at the property", false, true},
+	}
+	for _, tt := range tests {
+		freeCancellation, noPrepayment := paymentTerms(tt.cancellation, tt.payment)
+		if freeCancellation == nil || noPrepayment == nil {
+			t.Errorf("%s: paymentTerms(%q, %q) returned nil", tt.name, tt.cancellation, tt.payment)
+			continue
+		}
+		if *freeCancellation != tt.freeCancellation || *noPrepayment != tt.noPrepayment {
+			t.Errorf("%s: paymentTerms(%q, %q) = %v, %v; want %v, %v", tt.name, tt.cancellation, tt.payment,
+				*freeCancellation, *noPrepayment, tt.freeCancellation, tt.noPrepayment)
+		}
+	}
+
+	if freeCancellation, noPrepayment := paymentTerms("", "N/A"); freeCancellation != nil || noPrepayment != nil {
+		t.Errorf("paymentTerms without texts = %v, %v; want nil, nil", freeCancellation, noPrepayment)
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"1.2 km from downtown", 1200},
+		{"1,2 km vom Zentrum", 1200},
+		{"3 kilometres from centre", 3000},
+		{"1.234 km from centre", 1234},
+		{"1,25 km vom Zentrum", 1250},
+		{"1 kms from centre", 1000},
+		{"2.5kms from centre", 2500},
+		{"850 m from centre", 850},
+		{"400 metros del centro", 400},
+		{"0.8 miles from center", 0.8 * 1609.344},
+		{"2 mi from center", 2 * 1609.344},
+		{"500 feet from center", 500 * 0.3048},
+		{"In the city centre", 0},
+		{"Im Stadtzentrum", 0},
+	}
+	for _, tt := range tests {
+		if got := distanceMeters(tt.text); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("distanceMeters(%q) = %v; want %v", tt.text, got, tt.want)
+		}
+	}
+	for _, text := range []string{"", "N/A", "Beachfront"} {
+		if got := distanceMeters(text); !math.IsNaN(got) {
+			t.Errorf("distanceMeters(%q) = %v; want NaN", text, got)
+		}
+	}
+}
+
+func TestParseReviewCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"1,234 reviews", 1234},
+		{"1.234 Bewertungen", 1234},
+		{"1 234 avis", 1234},
+		{"12 reviews", 12},
+		{"8.6 · 1,234 reviews", 1234},
+		{"Scored 8,6 · 1.234 Bewertungen", 1234},
+		{"12345 reviews", 12345},
+		{"", 0},
+		{"No reviews yet", 0},
+	}
+	for _, tt := range tests {
+		if got := parseReviewCount(tt.text); got != tt.want {
+			t.Errorf("parseReviewCount(%q) = %d; want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParsePropertyID(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.booking.com/hotel/de/adlon.html", "de/adlon"},
+		{"https://www.booking.com/hotel/de/adlon.en-gb.html?aid=304142&checkin=2026-11-01", "de/adlon"},
+		{"/hotel/fr/le-meurice.fr.html#availability", "fr/le-meurice"},
+		{"https://www.booking.com/searchresults.html?hotel_id=12345", "12345"},
+		{"https://www.booking.com/searchresults.html", ""},
+		{"N/A", ""},
+	}
+	for _, tt := range tests {
+		if got := parsePropertyID(tt.url); got != tt.want {
+			t.Errorf("parsePropertyID(%q) = %q; want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	const page = "https://www.booking.com/searchresults.en-gb.html?ss=Berlin"
+	tests := []struct {
+		href, base, want string
+	}{
+		{
+			"/hotel/de/adlon.en-gb.html?aid=304142&label=gen173&checkin=2026-11-01&checkout=2026-11-03&group_adults=2#hotelTmpl",
+			page,
+			"https://www.booking.com/hotel/de/adlon.en-gb.html?checkin=2026-11-01&checkout=2026-11-03&group_adults=2",
+		},
+		{
+			"https://www.booking.com/hotel/de/adlon.html?sid=abc&no_rooms=1&age=5&age=9",
+			page,
+			"https://www.booking.com/hotel/de/adlon.html?age=5&age=9&no_rooms=1",
+		},
+		{"/hotel/de/adlon.html?srpvid=1", "", "https://www.booking.com/hotel/de/adlon.html"},
+		{"/hotel/de/adlon.html", "file:///tmp/snapshot.html", "https://www.booking.com/hotel/de/adlon.html"},
+		{"%zz", page, ""},
+	}
+	for _, tt := range tests {
+		if got := canonicalURL(tt.href, tt.base); got != tt.want {
+			t.Errorf("canonicalURL(%q, %q) = %q; want %q", tt.href, tt.base, got, tt.want)
+		}
+	}
+}
+
+// cardHrefs are title links the way results cards carry them: the stay
+// parameters buried among affiliate (aid, label), session (sid, all_sid),
+// search (dest_id, srpvid, srepoch, ucfs) and ranking parameters, often
+// with a fragment.
+var cardHrefs = []struct {
+	base, href, want string
+}{
+	{
+		"https://www.booking.com/searchresults.en-gb.html?ss=Austin",
+		"/hotel/us/the-driskill.en-gb.html?aid=304142&label=gen173nr-1FCAEoggI46AdIM1gEaOQBiAEBmAExuAEXyAEM2AEB6AEB-AECiAIBqAIDuAKP3p6xBsACAdICJGE5YjQ4NzEwLWM0YTMtNDFlZS1hYzMzLTRhNGVjNjQ4ZjU0NdgCBeACAQ&sid=8c1f4e3a2b9d7f6e5d4c3b2a19087f6e&all_sid=&checkin=2026-11-01&checkout=2026-11-03&dest_id=20126277&dest_type=city&dist=0&group_adults=2&group_children=0&hapos=1&hpos=1&no_rooms=1&req_adults=2&req_children=0&room1=A%2CA&sb_price_type=total&sr_order=popularity&srepoch=1792915200&srpvid=5e7a8b9c0d1e02f3&type=total&ucfs=1&#hotelTmpl",
+		"https://www.booking.com/hotel/us/the-driskill.en-gb.html?checkin=2026-11-01&checkout=2026-11-03&group_adults=2&group_children=0&no_rooms=1",
+	},
+	{
+		"https://www.booking.com/searchresults.html?ss=Berlin",
+		"https://www.booking.com/hotel/de/adlon.html?aid=356980&label=gog235jc-1DCAEoggI46AdIM1gDaDuIAQGYATG4ARfIAQzYAQPoAQH4AQKIAgGoAgO4AqKZ&sid=0f1e2d3c4b5a69788796a5b4c3d2e1f0&age=4&age=11&checkin=2026-12-20&checkout=2026-12-27&dest_id=-1746443&dest_type=city&dist=0&group_adults=2&group_children=2&hapos=3&hpos=3&no_rooms=1&req_adults=2&req_age=4&req_age=11&req_children=2&room1=A%2CA%2C4%2C11&sb_price_type=total&sr_order=popularity&srepoch=1792915200&srpvid=a1b2c3d4e5f60718&type=total&ucfs=1&#map_opened-hotel_address",
+		"https://www.booking.com/hotel/de/adlon.html?age=4&age=11&checkin=2026-12-20&checkout=2026-12-27&group_adults=2&group_children=2&no_rooms=1",
+	},
+	{
+		"https://www.booking.com/searchresults.de.html?ss=M%C3%BCnchen",
+		"/hotel/de/bayerischerhof.de.html?label=de-de-booking-desktop-Dd7K2cJvKb0Cg4Dp4v6yMQS652796016146%3Apl%3Ata%3Ap1%3Ap2%3Aac%3Aap%3Aneg%3Afi%3Atikwd-65526620%3Alp9042156%3Ali%3Adec%3Adm&sid=3d4c5b6a79808f7e6d5c4b3a29180716&aid=318615&ucfs=1&arphpl=1&checkin=2027-02-14&checkout=2027-02-15&dest_id=-1829149&dest_type=city&group_adults=1&req_adults=1&no_rooms=1&group_children=0&req_children=0&hpos=12&hapos=37&sr_order=popularity&nflt=class%3D5&srpvid=0a1b2c3d4e5f6071&srepoch=1792915200&all_sid=&from_sustainable_property_sr=1&from=searchresults#tab-reviews",
+		"https://www.booking.com/hotel/de/bayerischerhof.de.html?checkin=2027-02-14&checkout=2027-02-15&group_adults=1&group_children=0&no_rooms=1",
+	},
+	{
+		"https://www.booking.com/searchresults.pt-br.html?ss=Rio+de+Janeiro",
+		"/hotel/br/copacabana-palace.pt-br.html?aid=397594&label=gog235jc-1FCAEoggI46AdIM1gDaCCIAQGYATG4ARfIAQzYAQHoAQH4AQKIAgGoAgO4AqKZ&sid=77a8b9c0d1e2f30415263748596a7b8c&dest_id=-666610&dest_type=city&dist=0&hapos=5&hpos=5&matching_block_id=4382017_91235810_2_2_0&sb_price_type=total&sr_order=popularity&sr_pri_blocks=4382017_91235810_2_2_0__421300&srepoch=1792915200&srpvid=9f8e7d6c5b4a3928&type=total&ucfs=1&#availability_target",
+		"https://www.booking.com/hotel/br/copacabana-palace.pt-br.html",
+	},
+	{
+		// Saved snapshots have no web address to resolve against.
+		"file:///tmp/Paris_2026-11-01_2026-11-03.html",
+		"/hotel/fr/ritz-paris.fr.html?aid=304142&label=gen173nr-1FCAEoggI46AdIM1gEaE2IAQGYAQ24ARfIAQzYAQHoAQH4AQKIAgGoAgO4AqKZ&sid=5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d&checkin=2026-11-01&checkout=2026-11-03&group_adults=3&group_children=0&no_rooms=2&req_adults=3&req_children=0&srpvid=1f2e3d4c5b6a7980&ucfs=1&#hotelTmpl",
+		"https://www.booking.com/hotel/fr/ritz-paris.fr.html?checkin=2026-11-01&checkout=2026-11-03&group_adults=3&group_children=0&no_rooms=2",
+	},
+}
+
+func TestCanonicalURLCardHrefs(t *testing.T) {
+	for _, tt := range cardHrefs {
+		got := canonicalURL(tt.href, tt.base)
+		if got != tt.want {
+			t.Errorf("canonicalURL(%q, %q) = %q; want %q", tt.href, tt.base, got, tt.want)
+		}
+		// The links point at property pages, so the ID survives
+		// canonicalisation.
+		if id := parsePropertyID(tt.href); id == "" || parsePropertyID(got) != id {
+			t.Errorf("property ID of %q = %q, of its canonical URL %q", tt.href, id, parsePropertyID(got))
+		}
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	hotels := Hotels{
+		{PriceNumeric: 100, RatingValue: 8, HasReviews: true, DistanceMeters: 500},
+		{PriceNumeric: 300, RatingValue: 9, HasReviews: true, DistanceMeters: 1500},
+		// No price, no reviews and no distance: counted, but left out of
+		// every average.
+		{PriceNumeric: 0, RatingValue: 0, HasReviews: false, DistanceMeters: -1},
+		{PriceNumeric: 200, HasReviews: false, DistanceMeters: 0},
+	}
+	want := Stats{Count: 4, AvgPrice: 200, MinPrice: 100, MaxPrice: 300, AvgRating: 8.5, AvgDistanceMeters: 2000.0 / 3}
+	if got := hotels.Statistics(); got != want {
+		t.Errorf("Statistics() = %+v; want %+v", got, want)
+	}
+
+	if got := (Hotels{}).Statistics(); got != (Stats{}) {
+		t.Errorf("Statistics() of no hotels = %+v; want zeros", got)
+	}
+}
+
+// names returns the hotel names in order, for comparing results.
+func names(hs Hotels) []string {
+	var names []string
+	for _, hotel := range hs {
+		names = append(names, hotel.Name)
+	}
+	return names
+}
+
+func TestHotelsFilter(t *testing.T) {
+	hotels := Hotels{
+		{Name: "A", PriceNumeric: 200, StarRatingInt: 4},
+		{Name: "B", PriceNumeric: 0, StarRatingInt: 5},
+		{Name: "C", PriceNumeric: 100, StarRatingInt: 3},
+	}
+	tests := []struct {
+		name string
+		keep func(Hotel) bool
+		want []string
+	}{
+		{"all", func(Hotel) bool { return true }, []string{"A", "B", "C"}},
+		{"none", func(Hotel) bool { return false }, nil},
+		{"priced under 150", func(h Hotel) bool { return h.PriceNumeric > 0 && h.PriceNumeric < 150 }, []string{"C"}},
+		{"four stars or more", func(h Hotel) bool { return h.StarRatingInt >= 4 }, []string{"A", "B"}},
+	}
+	for _, tt := range tests {
+		if got := names(hotels.Filter(tt.keep)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Filter = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHotelsSort(t *testing.T) {
+	hotels := Hotels{
+		{Name: "A", PriceNumeric: 200, RatingValue: 8, HasReviews: true, DistanceMeters: 500},
+		// Unparsed price, no reviews and no distance: last in every order.
+		{Name: "B", PriceNumeric: 0, DistanceMeters: -1, Distance: "N/A"},
+		{Name: "C", PriceNumeric: 100, RatingValue: 9.5, HasReviews: true, DistanceMeters: 1500},
+		{Name: "D", PriceNumeric: 300, RatingValue: 8, HasReviews: true, Distance: "In the city centre"},
+		// Built without DistanceMeters, so Distance is parsed instead.
+		{Name: "E", PriceNumeric: 150, Distance: "2 km from centre"},
+	}
+	tests := []struct {
+		name string
+		sort func(Hotels) Hotels
+		want []string
+	}{
+		{"price", Hotels.SortByPrice, []string{"C", "E", "A", "D", "B"}},
+		// A and D tie and keep their order; so do B and E without reviews.
+		{"rating", Hotels.SortByRating, []string{"C", "A", "D", "B", "E"}},
+		{"distance", Hotels.SortByDistance, []string{"D", "A", "C", "E", "B"}},
+	}
+	for _, tt := range tests {
+		sorted := tt.sort(hotels)
+		if got := names(sorted); !slices.Equal(got, tt.want) {
+			t.Errorf("sort by %s = %v; want %v", tt.name, got, tt.want)
+		}
+
+		// The result is a copy: the input keeps its order and contents.
+		sorted[0].Name = "changed"
+		if got := names(hotels); !slices.Equal(got, []string{"A", "B", "C", "D", "E"}) {
+			t.Errorf("sort by %s changed its input to %v", tt.name, got)
+		}
+	}
+}
+
+func TestHotelsUnique(t *testing.T) {
+	hotels := Hotels{
+		{Name: "Adlon", Address: "Unter den Linden 77", Price: "first"},
+		{Name: "Adlon", Address: "Unter den Linden 77", Price: "second"},
+		// Same name at another address is another property.
+		{Name: "Adlon", Address: "Kurfürstendamm 1", Price: "other address"},
+		{Name: "Ritz", Address: "Potsdamer Platz 3", Price: "ritz"},
+	}
+	got := hotels.Unique()
+	var prices []string
+	for _, hotel := range got {
+		prices = append(prices, hotel.Price)
+	}
+	if want := []string{"first", "other address", "ritz"}; !slices.Equal(prices, want) {
+		t.Errorf("Unique kept %v; want %v", prices, want)
+	}
+
+	got[0].Name = "changed"
+	if hotels[0].Name != "Adlon" {
+		t.Error("Unique shares its result with the input")
+	}
+}
+
+func TestHotelsMerge(t *testing.T) {
+	earlier := Hotels{
+		{Name: "Adlon", Address: "Unter den Linden 77", Price: "€ 320"},
+		{Name: "Ritz", Address: "Potsdamer Platz 3", Price: "€ 280"},
+		// Repeated within one run: still dropped.
+		{Name: "Adlon", Address: "Unter den Linden 77", Price: "€ 310"},
+	}
+	later := Hotels{
+		{Name: "Ritz", Address: "Potsdamer Platz 3", Price: "€ 250"},
+		{Name: "Hostel", Address: "Alexanderplatz 1", Price: "€ 40"},
+		// Same name at another address is another property.
+		{Name: "Adlon", Address: "Kurfürstendamm 1", Price: "€ 150"},
+	}
+
+	tests := []struct {
+		name       string
+		hs, other  Hotels
+		wantNames  []string
+		wantPrices []string
+	}{
+		{"earlier first", earlier, later,
+			[]string{"Adlon", "Ritz", "Hostel", "Adlon"}, []string{"€ 320", "€ 280", "€ 40", "€ 150"}},
+		{"later first", later, earlier,
+			[]string{"Ritz", "Hostel", "Adlon", "Adlon"}, []string{"€ 250", "€ 40", "€ 150", "€ 320"}},
+		{"nothing to merge", earlier, nil,
+			[]string{"Adlon", "Ritz"}, []string{"€ 320", "€ 280"}},
+	}
+	for _, tt := range tests {
+		merged := tt.hs.Merge(tt.other)
+		var prices []string
+		for _, hotel := range merged {
+			prices = append(prices, hotel.Price)
+		}
+		if got := names(merged); !slices.Equal(got, tt.wantNames) || !slices.Equal(prices, tt.wantPrices) {
+			t.Errorf("%s: Merge = %v %v; want %v %v", tt.name, got, prices, tt.wantNames, tt.wantPrices)
+		}
+	}
+
+	// Merging leaves both inputs alone.
+	if len(earlier) != 3 || earlier[0].Price != "€ 320" || len(later) != 3 {
+		t.Errorf("Merge changed its inputs: %v, %v", earlier, later)
+	}
+}
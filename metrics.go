@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	hotelsScraped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hotels_scraped_total",
+		Help: "Number of hotels extracted across all cities.",
+	})
+	citiesCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "cities_completed_total",
+		Help: "Number of cities scraped successfully.",
+	})
+	citiesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cities_in_flight",
+		Help: "Number of cities currently being scraped.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(hotelsScraped, citiesCompleted, citiesInFlight)
+}
+
+// startMetricsServer serves Prometheus metrics on addr and keeps them up to
+// date from progressChan. The channel is drained even when addr is empty so
+// that checkpoints never block on a full buffer.
+func startMetricsServer(addr string) {
+	go func() {
+		for p := range progressChan {
+			switch p.Stage {
+			case "Starting":
+				citiesInFlight.Inc()
+			case "Completed":
+				citiesInFlight.Dec()
+				citiesCompleted.Inc()
+				hotelsScraped.Add(float64(p.Count))
+			case "Failed":
+				citiesInFlight.Dec()
+			}
+		}
+	}()
+
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		infof("Serving metrics on %s/metrics", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			errorf("Metrics server stopped: %v", err)
+		}
+	}()
+}
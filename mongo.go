@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTimeout bounds connecting and each per-city bulk write.
+const mongoTimeout = 2 * time.Minute
+
+// mongoHotel is the document layout. Unlike the flat files, photos and
+// amenities are arrays.
+type mongoHotel struct {
+	Name             string   `bson:"name"`
+	Price            string   `bson:"price"`
+	PriceNumeric     float64  `bson:"priceNumeric"`
+	Currency         string   `bson:"currency"`
+	CheckIn          string   `bson:"checkIn"`
+	CheckOut         string   `bson:"checkOut"`
+	ScrapeDate       string   `bson:"scrapeDate"`
+	ScrapedAt        string   `bson:"scrapedAt"`
+	RunID            string   `bson:"runID"`
+	City             string   `bson:"city"`
+	Rating           string   `bson:"rating"`
+	NumReviews       string   `bson:"numReviews"`
+	Address          string   `bson:"address"`
+	Amenities        []string `bson:"amenities"`
+	RoomType         string   `bson:"roomType"`
+	Cancellation     string   `bson:"cancellation"`
+	Distance         string   `bson:"distance"`
+	DistanceMeters   int      `bson:"distanceMeters"`
+	PropertyType     string   `bson:"propertyType"`
+	StarRating       string   `bson:"starRating"`
+	StarRatingInt    int      `bson:"starRatingInt"`
+	BookingURL       string   `bson:"bookingURL"`
+	Photos           []string `bson:"photos"`
+	GuestScoreBreak  string   `bson:"guestScoreBreak"`
+	Description      string   `bson:"description"`
+	HouseRules       string   `bson:"houseRules"`
+	Language         string   `bson:"language"`
+	Adults           int      `bson:"adults"`
+	Children         int      `bson:"children"`
+	Rooms            int      `bson:"rooms"`
+	ExtractionMs     int64    `bson:"extractionMs"`
+	RatingValue      float64  `bson:"ratingValue"`
+	ReviewCount      int      `bson:"reviewCount"`
+	HasReviews       bool     `bson:"hasReviews"`
+	StarRatingType   string   `bson:"starRatingType"`
+	PropertyID       string   `bson:"propertyId"`
+	CanonicalURL     string   `bson:"canonicalUrl"`
+	Latitude         float64  `bson:"latitude"`
+	Longitude        float64  `bson:"longitude"`
+	FreeCancellation *bool    `bson:"freeCancellation"`
+	NoPrepayment     *bool    `bson:"noPrepayment"`
+	PriceCurrency    string   `bson:"priceCurrency"`
+}
+
+// MongoStore inserts hotels into a MongoDB collection. One store is shared
+// by all cities; mongo.Client is safe for concurrent use.
+type MongoStore struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+// NewMongoStore connects and makes sure the (bookingURL, checkIn,
+// scrapeDate) index exists.
+func NewMongoStore(uri, database, collection string) (*MongoStore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to MongoDB: %w", err)
+	}
+	coll := client.Database(database).Collection(collection)
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "bookingURL", Value: 1}, {Key: "checkIn", Value: 1}, {Key: "scrapeDate", Value: 1}},
+		Options: options.Index().SetName("bookingURL_checkIn_scrapeDate"),
+	}); err != nil {
+		client.Disconnect(ctx)
+		return nil, fmt.Errorf("could not create index on %s.%s: %w", database, collection, err)
+	}
+	return &MongoStore{client: client, collection: coll}, nil
+}
+
+// Save bulk-inserts the hotels of one city without stopping at the first
+// bad document. It returns how many were inserted and one error per
+// document that was not; err is set when the write failed as a whole.
+func (s *MongoStore) Save(city string, hotels []Hotel) (inserted int, docErrs []error, err error) {
+	if len(hotels) == 0 {
+		return 0, nil, nil
+	}
+
+	scrapeDate := time.Now().Format("2006-01-02")
+	docs := make([]interface{}, len(hotels))
+	for i, h := range hotels {
+		doc := mongoHotel{
+			Name: h.Name, Price: h.Price, PriceNumeric: h.PriceNumeric, Currency: h.Currency,
+			CheckIn: h.CheckIn, CheckOut: h.CheckOut, ScrapeDate: scrapeDate, ScrapedAt: h.ScrapedAt,
+			RunID: h.RunID, City: city, Rating: h.Rating, NumReviews: h.NumReviews, Address: h.Address,
+			Amenities: h.amenities(), RoomType: h.RoomType, Cancellation: h.Cancellation,
+			Distance: h.Distance, DistanceMeters: h.DistanceMeters, PropertyType: h.PropertyType,
+			StarRating: h.StarRating, StarRatingInt: h.StarRatingInt, BookingURL: h.BookingURL,
+			Photos: h.photos(), GuestScoreBreak: h.GuestScoreBreak, Description: h.Description,
+			HouseRules: h.HouseRules, Language: h.Language, Adults: h.Adults, Children: h.Children,
+			Rooms: h.Rooms, ExtractionMs: h.ExtractionMs,
+			RatingValue: h.RatingValue, ReviewCount: h.ReviewCount, HasReviews: h.HasReviews,
+			StarRatingType:   h.StarRatingType,
+			PropertyID:       h.PropertyID,
+			CanonicalURL:     h.CanonicalURL,
+			Latitude:         h.Latitude,
+			Longitude:        h.Longitude,
+			FreeCancellation: h.FreeCancellation,
+			NoPrepayment:     h.NoPrepayment,
+			PriceCurrency:    h.PriceCurrency,
+		}
+		docs[i] = doc
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+
+	_, err = s.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case err == nil:
+		return len(hotels), nil, nil
+	case errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0:
+		for _, we := range bulkErr.WriteErrors {
+			name := "?"
+			if we.Index >= 0 && we.Index < len(hotels) {
+				name = hotels[we.Index].Name
+			}
+			docErrs = append(docErrs, fmt.Errorf("%q: %s (code %d)", name, we.Message, we.Code))
+		}
+		return len(hotels) - len(bulkErr.WriteErrors), docErrs, nil
+	}
+	return 0, nil, fmt.Errorf("could not insert into MongoDB: %w", err)
+}
+
+func (s *MongoStore) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoTimeout)
+	defer cancel()
+	return s.client.Disconnect(ctx)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes every extracted hotel as JSON to
+// <subject>.<city>. The connection is shared by all city goroutines, which
+// nats.Conn allows. While the broker is unreachable the client reconnects in
+// the background and buffers messages; once its buffer is full Publish fails,
+// and callers log the failure and keep writing files.
+type NATSPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNATSPublisher(url, subject string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("booking-scraper"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				warnf("Disconnected from NATS: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			infof("Reconnected to NATS at %s", conn.ConnectedUrlRedacted())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, subject: subject}, nil
+}
+
+// natsToken makes a city usable as one subject token: NATS splits subjects
+// on dots and does not allow whitespace or wildcards in them.
+var natsToken = strings.NewReplacer(".", "", " ", "_", "\t", "_", "*", "", ">", "")
+
+// Subject returns the subject hotels of city are published to.
+func (p *NATSPublisher) Subject(city string) string {
+	return p.subject + "." + natsToken.Replace(city)
+}
+
+// Publish sends one hotel.
+func (p *NATSPublisher) Publish(city string, hotel Hotel) error {
+	data, err := json.Marshal(hotel)
+	if err != nil {
+		return fmt.Errorf("could not encode %q: %w", hotel.Name, err)
+	}
+	if err := p.conn.Publish(p.Subject(city), data); err != nil {
+		return fmt.Errorf("could not publish %q: %w", hotel.Name, err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection.
+func (p *NATSPublisher) Close() error {
+	defer p.conn.Close()
+	if err := p.conn.FlushTimeout(10 * time.Second); err != nil {
+		return fmt.Errorf("could not flush NATS messages: %w", err)
+	}
+	return nil
+}
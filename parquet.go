@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetHotel is the Parquet row layout. Counts and the parsed price are
+// typed columns; the other scraped text fields stay strings until they are
+// parsed into numbers.
+type parquetHotel struct {
+	Name             string  `parquet:"name"`
+	Price            string  `parquet:"price"`
+	PriceNumeric     float64 `parquet:"price_numeric"`
+	CheckIn          string  `parquet:"check_in"`
+	CheckOut         string  `parquet:"check_out"`
+	Rating           string  `parquet:"rating"`
+	NumReviews       string  `parquet:"num_reviews"`
+	Address          string  `parquet:"address"`
+	Amenities        string  `parquet:"amenities"`
+	RoomType         string  `parquet:"room_type"`
+	Cancellation     string  `parquet:"cancellation"`
+	Distance         string  `parquet:"distance"`
+	DistanceMeters   int32   `parquet:"distance_meters"`
+	PropertyType     string  `parquet:"property_type"`
+	StarRating       string  `parquet:"star_rating"`
+	StarRatingInt    int32   `parquet:"star_rating_int"`
+	BookingURL       string  `parquet:"booking_url"`
+	Photos           string  `parquet:"photos"`
+	GuestScoreBreak  string  `parquet:"guest_score_break"`
+	Description      string  `parquet:"description"`
+	Adults           int32   `parquet:"adults"`
+	Children         int32   `parquet:"children"`
+	Rooms            int32   `parquet:"rooms"`
+	Currency         string  `parquet:"currency"`
+	HouseRules       string  `parquet:"house_rules"`
+	Language         string  `parquet:"language"`
+	ScrapedAt        string  `parquet:"scraped_at"`
+	RunID            string  `parquet:"run_id"`
+	SearchCity       string  `parquet:"search_city"`
+	ExtractionMs     int64   `parquet:"extraction_ms"`
+	RatingValue      float64 `parquet:"rating_value"`
+	ReviewCount      int32   `parquet:"review_count"`
+	HasReviews       bool    `parquet:"has_reviews"`
+	StarRatingType   string  `parquet:"star_rating_type"`
+	PropertyID       string  `parquet:"property_id"`
+	CanonicalURL     string  `parquet:"canonical_url"`
+	Latitude         float64 `parquet:"latitude"`
+	Longitude        float64 `parquet:"longitude"`
+	FreeCancellation *bool   `parquet:"free_cancellation,optional"`
+	NoPrepayment     *bool   `parquet:"no_prepayment,optional"`
+	PriceCurrency    string  `parquet:"price_currency"`
+}
+
+// ParquetWriter writes one Parquet file per city for DuckDB and Spark, which
+// choke on the commas and newlines inside CSV descriptions.
+type ParquetWriter struct{}
+
+func (ParquetWriter) Ext() string { return "parquet" }
+
+func (ParquetWriter) Write(w io.Writer, hotels []Hotel) error {
+	rows := make([]parquetHotel, len(hotels))
+	for i, hotel := range hotels {
+		rows[i] = parquetHotel{
+			Name: hotel.Name, Price: hotel.Price, PriceNumeric: hotel.PriceNumeric, CheckIn: hotel.CheckIn, CheckOut: hotel.CheckOut,
+			Rating: hotel.Rating, NumReviews: hotel.NumReviews, Address: hotel.Address,
+			Amenities: hotel.Amenities, RoomType: hotel.RoomType, Cancellation: hotel.Cancellation,
+			Distance: hotel.Distance, DistanceMeters: int32(hotel.DistanceMeters), PropertyType: hotel.PropertyType, StarRating: hotel.StarRating, StarRatingInt: int32(hotel.StarRatingInt),
+			BookingURL: hotel.BookingURL, Photos: hotel.Photos, GuestScoreBreak: hotel.GuestScoreBreak,
+			Description: hotel.Description, Adults: int32(hotel.Adults), Children: int32(hotel.Children),
+			Rooms: int32(hotel.Rooms), Currency: hotel.Currency, HouseRules: hotel.HouseRules,
+			Language: hotel.Language, ScrapedAt: hotel.ScrapedAt, RunID: hotel.RunID,
+			SearchCity: hotel.SearchCity, ExtractionMs: hotel.ExtractionMs,
+			RatingValue: hotel.RatingValue, ReviewCount: int32(hotel.ReviewCount), HasReviews: hotel.HasReviews,
+			StarRatingType:   hotel.StarRatingType,
+			PropertyID:       hotel.PropertyID,
+			CanonicalURL:     hotel.CanonicalURL,
+			Latitude:         hotel.Latitude,
+			Longitude:        hotel.Longitude,
+			FreeCancellation: hotel.FreeCancellation,
+			NoPrepayment:     hotel.NoPrepayment,
+			PriceCurrency:    hotel.PriceCurrency,
+		}
+	}
+
+	writer := parquet.NewGenericWriter[parquetHotel](w)
+	if _, err := writer.Write(rows); err != nil {
+		writer.Close()
+		return fmt.Errorf("error writing Parquet rows: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error finishing Parquet file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetWriterRoundTrip(t *testing.T) {
+	yes := true
+	hotels := Hotels{
+		{
+			Name: "Hotel Adlon, Kempinski", Price: "€ 320", PriceNumeric: 320, CheckIn: "2026-11-01",
+			CheckOut: "2026-11-03", Description: "Line one\nline two", DistanceMeters: 1200,
+			StarRatingInt: 5, Adults: 2, Rooms: 1, Currency: "EUR", ExtractionMs: 42,
+			RatingValue: 9.1, ReviewCount: 2345, HasReviews: true, PropertyID: "de/adlon",
+			Latitude: 52.5163, Longitude: 13.3806, FreeCancellation: &yes,
+			PriceCurrency: "EUR",
+		},
+		{Name: "Hostel", Price: "N/A", DistanceMeters: -1},
+	}
+
+	var buf bytes.Buffer
+	if err := (ParquetWriter{}).Write(&buf, hotels); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	rows, err := parquet.Read[parquetHotel](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(rows) != len(hotels) {
+		t.Fatalf("read %d rows; want %d", len(rows), len(hotels))
+	}
+
+	for i, hotel := range hotels {
+		row := rows[i]
+		got := Hotel{
+			Name: row.Name, Price: row.Price, PriceNumeric: row.PriceNumeric, CheckIn: row.CheckIn,
+			CheckOut: row.CheckOut, Description: row.Description, DistanceMeters: int(row.DistanceMeters),
+			StarRatingInt: int(row.StarRatingInt), Adults: int(row.Adults), Rooms: int(row.Rooms),
+			Currency: row.Currency, ExtractionMs: row.ExtractionMs, RatingValue: row.RatingValue,
+			ReviewCount: int(row.ReviewCount), HasReviews: row.HasReviews, PropertyID: row.PropertyID,
+			Latitude: row.Latitude, Longitude: row.Longitude, FreeCancellation: row.FreeCancellation,
+			NoPrepayment: row.NoPrepayment, PriceCurrency: row.PriceCurrency,
+		}
+		if !reflect.DeepEqual(got, hotel) {
+			t.Errorf("row %d = %+v; want %+v", i, got, hotel)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresColumns lists the columns PostgresStore fills, in insert order.
+var postgresColumns = []string{
+	"city", "scraped_at", "name", "price", "check_in", "check_out", "rating", "num_reviews",
+	"address", "amenities", "room_type", "cancellation", "distance", "property_type",
+	"star_rating", "booking_url", "photos", "guest_score_break", "description",
+	"adults", "children", "rooms", "currency", "house_rules", "language", "price_numeric",
+	"star_rating_int", "run_id", "search_city", "distance_meters", "extraction_ms",
+	"rating_value", "review_count", "has_reviews", "star_rating_type", "property_id", "canonical_url",
+	"latitude", "longitude", "free_cancellation", "no_prepayment", "price_currency",
+}
+
+// postgresSchema creates the table, adds columns introduced after the first
+// release and builds the unique index the upsert relies on.
+//
+//go:embed postgres_schema.sql
+var postgresSchema string
+
+// postgresKey is the conflict target of the upsert: one row per property
+// and stay.
+var postgresKey = []string{"name", "address", "check_in", "check_out"}
+
+// postgresBatchSize keeps each INSERT well under PostgreSQL's limit of 65535
+// bind parameters.
+const postgresBatchSize = 500
+
+var postgresTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PostgresStore upserts the hotels of a single city into a PostgreSQL
+// table, keyed on postgresKey. Inserted and Updated count the rows of the
+// last Save.
+type PostgresStore struct {
+	db    *sql.DB
+	table string
+	city  string
+
+	Inserted int
+	Updated  int
+}
+
+func NewPostgresStore(dsn, table, city string) (*PostgresStore, error) {
+	if !postgresTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+
+	// The schema is several statements; without arguments pgx sends it
+	// over the simple protocol, which accepts that in one Exec.
+	indexName := strings.ReplaceAll(table, ".", "_") + "_stay_key"
+	index := indexName
+	if schema, _, ok := strings.Cut(table, "."); ok {
+		index = schema + "." + indexName
+	}
+	schema := strings.NewReplacer("{{table}}", table, "{{index_name}}", indexName, "{{index}}", index).Replace(postgresSchema)
+	err = withPostgresRetry(func() error {
+		_, err := db.Exec(schema)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create table %s: %w", table, err)
+	}
+
+	return &PostgresStore{db: db, table: table, city: city}, nil
+}
+
+// Save upserts hotels in batches inside one transaction. A transient
+// connection error retries the whole transaction, so a city is never
+// half-written.
+func (s *PostgresStore) Save(hotels []Hotel) error {
+	return withPostgresRetry(func() error {
+		return s.save(hotels)
+	})
+}
+
+func (s *PostgresStore) save(hotels []Hotel) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Every column but the key is refreshed on conflict.
+	var updates []string
+	for _, column := range postgresColumns {
+		if !slices.Contains(postgresKey, column) {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+
+	s.Inserted, s.Updated = 0, 0
+	scrapedAt := time.Now()
+	for start := 0; start < len(hotels); start += postgresBatchSize {
+		end := min(start+postgresBatchSize, len(hotels))
+		batch := hotels[start:end]
+
+		var query strings.Builder
+		fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", s.table, strings.Join(postgresColumns, ", "))
+		args := make([]any, 0, len(batch)*len(postgresColumns))
+		for i, hotel := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString("(")
+			for j := range postgresColumns {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				fmt.Fprintf(&query, "$%d", len(args)+j+1)
+			}
+			query.WriteString(")")
+			args = append(args,
+				s.city, scrapedAt, hotel.Name, hotel.Price, hotel.CheckIn, hotel.CheckOut, hotel.Rating,
+				hotel.NumReviews, hotel.Address, hotel.Amenities, hotel.RoomType, hotel.Cancellation,
+				hotel.Distance, hotel.PropertyType, hotel.StarRating, hotel.BookingURL, hotel.Photos,
+				hotel.GuestScoreBreak, hotel.Description, hotel.Adults, hotel.Children, hotel.Rooms,
+				hotel.Currency, hotel.HouseRules, hotel.Language, hotel.PriceNumeric,
+				hotel.StarRatingInt, hotel.RunID, hotel.SearchCity, hotel.DistanceMeters, hotel.ExtractionMs,
+				hotel.RatingValue, hotel.ReviewCount, hotel.HasReviews, hotel.StarRatingType, hotel.PropertyID,
+				hotel.CanonicalURL, nullCoordinate(hotel, hotel.Latitude), nullCoordinate(hotel, hotel.Longitude),
+				hotel.FreeCancellation, hotel.NoPrepayment, hotel.PriceCurrency,
+			)
+		}
+
+		// xmax is 0 only for freshly inserted rows.
+		fmt.Fprintf(&query, " ON CONFLICT (%s) DO UPDATE SET %s RETURNING (xmax = 0)",
+			strings.Join(postgresKey, ", "), strings.Join(updates, ", "))
+
+		rows, err := tx.Query(query.String(), args...)
+		if err != nil {
+			return fmt.Errorf("error upserting hotels %d-%d: %w", start+1, end, err)
+		}
+		for rows.Next() {
+			var inserted bool
+			if err := rows.Scan(&inserted); err != nil {
+				rows.Close()
+				return fmt.Errorf("error upserting hotels %d-%d: %w", start+1, end, err)
+			}
+			if inserted {
+				s.Inserted++
+			} else {
+				s.Updated++
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error upserting hotels %d-%d: %w", start+1, end, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// withPostgresRetry runs fn up to three times, backing off between attempts,
+// as long as it fails with a transient error.
+func withPostgresRetry(fn func() error) error {
+	const attempts = 3
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil || !isTransientPostgresError(err) {
+			return err
+		}
+		if i < attempts-1 {
+			delay := time.Duration(1<<i) * time.Second
+			warnf("Transient PostgreSQL error, retrying in %s: %v", delay, err)
+			time.Sleep(delay)
+		}
+	}
+	return err
+}
+
+// isTransientPostgresError reports whether err is worth retrying: dropped
+// connections, network errors, and the SQLSTATE classes for connection
+// exceptions (08), serialization failures (40001), deadlocks (40P01) and
+// server shutdown (57P01-57P03).
+func isTransientPostgresError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		code := pgErr.SQLState()
+		return strings.HasPrefix(code, "08") || code == "40001" || code == "40P01" ||
+			code == "57P01" || code == "57P02" || code == "57P03"
+	}
+	return false
+}
+
+// PostgresSink upserts each job's hotels into Table of the database at DSN.
+type PostgresSink struct {
+	DSN   string
+	Table string
+}
+
+func (PostgresSink) Name() string { return "PostgreSQL" }
+
+func (s PostgresSink) Save(city string, hotels []Hotel) (string, error) {
+	inserted, updated, err := saveToPostgres(s.DSN, s.Table, city, hotels)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("Upserted hotels into PostgreSQL", "city", city, "table", s.Table, "inserted", inserted, "updated", updated)
+	return "postgres:" + s.Table, nil
+}
+
+// saveToPostgres opens a store for city, saves hotels and closes it again,
+// returning how many rows were inserted and updated.
+func saveToPostgres(dsn, table, city string, hotels []Hotel) (inserted, updated int, err error) {
+	store, err := NewPostgresStore(dsn, table, city)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := store.Save(hotels); err != nil {
+		store.Close()
+		return 0, 0, err
+	}
+	return store.Inserted, store.Updated, store.Close()
+}
@@ -0,0 +1,81 @@
+-- Schema for the PostgreSQL export. {{table}} is replaced with the
+-- -postgres-table name, {{index_name}} and {{index}} with the name of its
+-- unique index, unqualified and schema-qualified.
+
+CREATE TABLE IF NOT EXISTS {{table}} (
+	id                BIGSERIAL PRIMARY KEY,
+	city              TEXT NOT NULL,
+	scraped_at        TIMESTAMPTZ NOT NULL,
+	name              TEXT,
+	price             TEXT,
+	check_in          TEXT,
+	check_out         TEXT,
+	rating            TEXT,
+	num_reviews       TEXT,
+	address           TEXT,
+	amenities         TEXT,
+	room_type         TEXT,
+	cancellation      TEXT,
+	distance          TEXT,
+	property_type     TEXT,
+	star_rating       TEXT,
+	booking_url       TEXT,
+	photos            TEXT,
+	guest_score_break TEXT,
+	description       TEXT,
+	adults            INTEGER,
+	children          INTEGER,
+	rooms             INTEGER,
+	currency          TEXT,
+	house_rules       TEXT,
+	language          TEXT,
+	price_numeric     DOUBLE PRECISION,
+	star_rating_int   INTEGER,
+	run_id            TEXT,
+	search_city       TEXT,
+	distance_meters   INTEGER,
+	extraction_ms     BIGINT,
+	rating_value      DOUBLE PRECISION,
+	review_count      INTEGER,
+	has_reviews       BOOLEAN,
+	star_rating_type  TEXT,
+	property_id       TEXT,
+	canonical_url     TEXT,
+	latitude          DOUBLE PRECISION,
+	longitude         DOUBLE PRECISION,
+	free_cancellation BOOLEAN,
+	no_prepayment     BOOLEAN,
+	price_currency    TEXT
+);
+
+-- Columns added after the first release.
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS price_numeric DOUBLE PRECISION;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS star_rating_int INTEGER;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS run_id TEXT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS search_city TEXT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS distance_meters INTEGER;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS extraction_ms BIGINT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS rating_value DOUBLE PRECISION;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS review_count INTEGER;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS has_reviews BOOLEAN;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS star_rating_type TEXT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS property_id TEXT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS canonical_url TEXT;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS free_cancellation BOOLEAN;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS no_prepayment BOOLEAN;
+ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS price_currency TEXT;
+
+-- Tables from before the upsert hold one row per run; keep the newest row
+-- of each stay so the unique index can be built.
+DO $$
+BEGIN
+	IF to_regclass('{{index}}') IS NULL THEN
+		DELETE FROM {{table}} a USING {{table}} b
+		WHERE a.id < b.id AND a.name = b.name AND a.address = b.address
+			AND a.check_in = b.check_in AND a.check_out = b.check_out;
+	END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{index_name}} ON {{table}} (name, address, check_in, check_out);
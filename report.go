@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// reportTemplate renders a RunSummary as a single page. Failed jobs are
+// shown in red with their error and any partial files instead of being left
+// out.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"base": filepath.Base,
+	"price": func(v float64) string {
+		if v == 0 {
+			return "–"
+		}
+		return fmt.Sprintf("%.2f", v)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scrape report {{.Summary.RunID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.num { text-align: right; }
+tr.failed td { color: #b00; background: #fee; }
+img { width: 240px; margin: 2px; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>Scrape report</h1>
+<p>Run {{.Summary.RunID}}: {{.Summary.Started.Format "2006-01-02 15:04:05"}} to {{.Summary.Finished.Format "15:04:05"}} ({{.Summary.Duration}}),
+{{len .Summary.Succeeded}} of {{len .Summary.Attempted}} cities succeeded, {{.Summary.TotalHotels}} hotels.</p>
+<table>
+<tr><th>City</th><th>Stay</th><th>Hotels</th><th>Min price</th><th>Avg price</th><th>Max price</th><th>Duration</th><th>Files</th><th>Warnings / error</th></tr>
+{{range .Jobs}}{{if .Error}}<tr class="failed">
+<td>{{.City}}</td><td>{{.Stay}}</td><td colspan="5"></td>
+<td>{{range .Files}}<a href="{{.}}">{{base .}}</a><br>{{end}}</td><td>{{.Error}}</td>
+</tr>{{else}}<tr>
+<td>{{.City}}</td><td>{{.Stay}}</td><td class="num">{{.Hotels}}</td>
+<td class="num">{{price .MinPrice}}</td><td class="num">{{price .AvgPrice}}</td><td class="num">{{price .MaxPrice}}</td>
+<td>{{.Duration}}</td>
+<td>{{range .Files}}<a href="{{.}}">{{base .}}</a><br>{{end}}</td>
+<td>{{range .Warnings}}{{.}}<br>{{end}}</td>
+</tr>{{end}}
+{{end}}</table>
+{{range .Jobs}}{{if .Screenshots}}
+<h2>{{.City}} {{.Stay}}</h2>
+<p>{{range .Screenshots}}<a href="{{.}}"><img src="{{.}}" alt="{{base .}}"></a>{{end}}</p>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// WriteReport renders the summary as <dir>/<date>/report_<runID>.html, with
+// links relative to the report so the directory can be moved as a whole.
+// Call it after Write, which stamps the finish time.
+func (s *RunSummary) WriteReport(dir string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(dir, s.Started.Format("2006-01-02"), "report_"+s.RunID+".html")
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", fmt.Errorf("could not create report directory: %w", err)
+	}
+
+	jobs := make([]JobReport, len(s.Jobs))
+	for i, job := range s.Jobs {
+		job.Files = relativePaths(filepath.Dir(path), job.Files)
+		job.Screenshots = relativePaths(filepath.Dir(path), job.Screenshots)
+		jobs[i] = job
+	}
+
+	data := struct {
+		Summary *RunSummary
+		Jobs    []JobReport
+	}{s, jobs}
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		return reportTemplate.Execute(w, data)
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not write run report: %w", err)
+	}
+	return path, nil
+}
+
+// relativePaths rewrites paths relative to base, keeping any it cannot.
+func relativePaths(base string, paths []string) []string {
+	rel := make([]string, len(paths))
+	for i, p := range paths {
+		rel[i] = p
+		absBase, err1 := filepath.Abs(base)
+		absPath, err2 := filepath.Abs(p)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if r, err := filepath.Rel(absBase, absPath); err == nil {
+			rel[i] = filepath.ToSlash(r)
+		}
+	}
+	return rel
+}
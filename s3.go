@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3UploadTimeout bounds a single upload. Uploads run after a job's context
+// may already be close to its deadline, so they get their own.
+const s3UploadTimeout = 5 * time.Minute
+
+// S3Uploader copies result files to S3 as soon as a job completes, for
+// machines whose disks do not outlive the run. Credentials and region come
+// from the usual AWS environment variables and config files.
+type S3Uploader struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func NewS3Uploader(bucket, prefix string) (*S3Uploader, error) {
+	// The SDK's standard retryer already backs off on throttling and
+	// transient network errors; allow it a few more attempts than default.
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRetryMaxAttempts(5))
+	if err != nil {
+		return nil, fmt.Errorf("could not load AWS configuration: %w", err)
+	}
+	return &S3Uploader{client: s3.NewFromConfig(awsCfg), bucket: bucket, prefix: prefix}, nil
+}
+
+// Key returns <prefix>/<date>/<city>/<filename> for a local file.
+func (u *S3Uploader) Key(city, filePath string) string {
+	return path.Join(u.prefix, time.Now().Format("2006-01-02"), city, filepath.Base(filePath))
+}
+
+// Upload copies filePath to S3 and returns the s3:// URL it was written to.
+func (u *S3Uploader) Upload(city, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not open %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3UploadTimeout)
+	defer cancel()
+
+	key := u.Key(city, filePath)
+	if _, err := u.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(key),
+		Body:   file,
+	}); err != nil {
+		return "", fmt.Errorf("could not upload %s to s3://%s/%s: %w", filePath, u.bucket, key, err)
+	}
+	return "s3://" + u.bucket + "/" + key, nil
+}
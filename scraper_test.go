@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"golang.org/x/time/rate"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		price, locale string
+		amount        float64
+		currency      string
+		wantErr       bool
+	}{
+		{price: "€ 1.234,56", amount: 1234.56, currency: "EUR"},
+		{price: "€ 1.234,56", locale: "de-DE", amount: 1234.56, currency: "EUR"},
+		{price: "US$1,234", amount: 1234, currency: "USD"},
+		{price: "US$1,234", locale: "en-US", amount: 1234, currency: "USD"},
+		{price: "$89.50", amount: 89.5, currency: "USD"},
+		{price: "1 234 kr", amount: 1234},
+		{price: "1 234 kr", locale: "sv-SE", amount: 1234},
+		{price: "1 234,50 €", locale: "fr-FR", amount: 1234.5, currency: "EUR"},
+		{price: "CHF 1'234.50", locale: "de-CH", amount: 1234.5, currency: "CHF"},
+		{price: "€120 €95", amount: 95, currency: "EUR"},
+		{price: "€89 – €120", amount: 89, currency: "EUR"},
+		{price: "£89", amount: 89, currency: "GBP"},
+		{price: "£1,234.56", locale: "en-GB", amount: 1234.56, currency: "GBP"},
+		{price: "Price from US$ 120 for 2 nights", amount: 120, currency: "USD"},
+		{price: "2 nights, 2 adults US$ 350", amount: 350, currency: "USD"},
+		{price: "R$ 500", amount: 500, currency: "BRL"},
+		{price: "MX$1,500", amount: 1500, currency: "MXN"},
+		{price: "USD 1,234.56", amount: 1234.56, currency: "USD"},
+		{price: "€ 1.234", locale: "en-US", amount: 1234, currency: "EUR"},
+		{price: "€ 1.234", locale: "de-DE", amount: 1234, currency: "EUR"},
+		{price: "", wantErr: true},
+		{price: "N/A", wantErr: true},
+	}
+	for _, tt := range tests {
+		amount, currency, err := parsePrice(tt.price, tt.locale)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePrice(%q, %q) = %v, %q; want error", tt.price, tt.locale, amount, currency)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePrice(%q, %q): %v", tt.price, tt.locale, err)
+			continue
+		}
+		if amount != tt.amount || currency != tt.currency {
+			t.Errorf("parsePrice(%q, %q) = %v, %q; want %v, %q", tt.price, tt.locale, amount, currency, tt.amount, tt.currency)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		number string
+		want   float64
+	}{
+		{"1,234", 1234},
+		{"1.234", 1234},
+		{"89,50", 89.5},
+		{"89.5", 89.5},
+		{"1.234,56", 1234.56},
+		{"1,234.56", 1234.56},
+		{"1,234,567", 1234567},
+		{"120.", 120},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.number)
+		if err != nil || got != tt.want {
+			t.Errorf("parseAmount(%q) = %v, %v; want %v", tt.number, got, err, tt.want)
+		}
+	}
+	for _, number := range []string{"", "N/A"} {
+		if got, err := parseAmount(number); err == nil {
+			t.Errorf("parseAmount(%q) = %v; want error", number, got)
+		}
+	}
+}
+
+func TestParseLocaleAmount(t *testing.T) {
+	tests := []struct {
+		number, locale string
+		want           float64
+	}{
+		{"1.234,56", "de-DE", 1234.56},
+		{"1,234", "en-US", 1234},
+		{"1,234", "de-DE", 1.234},
+		{"1 234", "sv-SE", 1234},
+		{"1 234,5", "fr-FR", 1234.5},
+		{"1 234,5", "fr_FR", 1234.5},
+		{"1'234.50", "de-CH", 1234.5},
+		{"1’234.50", "it-CH", 1234.5},
+		{"1.234,56", "", 1234.56},
+	}
+	for _, tt := range tests {
+		got, err := parseLocaleAmount(tt.number, tt.locale)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseLocaleAmount(%q, %q) = %v, %v; want %v", tt.number, tt.locale, got, err, tt.want)
+		}
+	}
+	for _, number := range []string{"", "N/A"} {
+		if got, err := parseLocaleAmount(number, "en-GB"); err == nil {
+			t.Errorf("parseLocaleAmount(%q) = %v; want error", number, got)
+		}
+	}
+}
+
+func TestValidateBookingURL(t *testing.T) {
+	tests := []struct {
+		bookingURL string
+		valid      bool
+	}{
+		{"https://www.booking.com/hotel/de/adlon.html?aid=1", true},
+		{"/hotel/de/adlon.html?aid=1", true},
+		{"/hotel/de/adlon.en-gb.html", true},
+		{"/searchresults.html?ss=Berlin", false},
+		{"hotel/de/adlon.html", false},
+		{"javascript:void(0)", false},
+		{"N/A", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		hotel := Hotel{Name: "Adlon", Price: "€ 320", BookingURL: tt.bookingURL}
+		if err := hotel.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate with BookingURL %q = %v; want valid %v", tt.bookingURL, err, tt.valid)
+		}
+	}
+
+	// CanonicalURL is derived from BookingURL and cannot vouch for it.
+	hotel := Hotel{Name: "Adlon", Price: "€ 320", BookingURL: "N/A", CanonicalURL: "https://www.booking.com/hotel/de/adlon.html"}
+	if err := hotel.Validate(); err == nil {
+		t.Errorf("Validate accepted BookingURL %q because of CanonicalURL", hotel.BookingURL)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	base := time.Millisecond
+	for attempt := 0; attempt < 6; attempt++ {
+		nominal := base << attempt
+		low, high := nominal*8/10, nominal*12/10
+		for i := 0; i < 100; i++ {
+			if got := backoffDelay(attempt, base, rng); got < low || got > high {
+				t.Fatalf("backoffDelay(%d, %s) = %s; want within [%s, %s]", attempt, base, got, low, high)
+			}
+		}
+	}
+
+	// Long waits are capped at a minute, jitter included.
+	for i := 0; i < 100; i++ {
+		if got := backoffDelay(10, time.Second, rng); got < 48*time.Second || got > time.Minute {
+			t.Fatalf("backoffDelay(10, 1s) = %s; want within [48s, 1m]", got)
+		}
+	}
+}
+
+// fakePage fails its first `failures` navigations, with a 429 response when
+// status is set and an error otherwise. Other Page methods are not
+// implemented.
+type fakePage struct {
+	playwright.Page
+	failures, gotos int
+	status          int
+}
+
+type fakeResponse struct {
+	playwright.Response
+	status int
+}
+
+func (r fakeResponse) Status() int { return r.status }
+
+func (p *fakePage) Goto(url string, options ...playwright.PageGotoOptions) (playwright.Response, error) {
+	p.gotos++
+	if p.gotos > p.failures {
+		return fakeResponse{status: http.StatusOK}, nil
+	}
+	if p.status != 0 {
+		return fakeResponse{status: p.status}, nil
+	}
+	return nil, errors.New("net::ERR_CONNECTION_RESET")
+}
+
+func TestNavigateWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      fakePage
+		gotos     int
+		rotations int
+		wantErr   bool
+	}{
+		{"first try", fakePage{}, 1, 0, false},
+		{"second try", fakePage{failures: 1}, 2, 1, false},
+		{"rate limited", fakePage{failures: 2, status: http.StatusTooManyRequests}, 3, 2, false},
+		{"gives up", fakePage{failures: 5}, 3, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &tt.page
+			rotations := 0
+			rotate := func(p playwright.Page) (playwright.Page, error) {
+				rotations++
+				return p, nil
+			}
+			limiter := rate.NewLimiter(rate.Inf, 1)
+			rng := rand.New(rand.NewSource(1))
+
+			_, err := navigateWithRetry(context.Background(), page, "https://www.booking.com", limiter, rng, rotate, time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("navigateWithRetry error = %v; want error %v", err, tt.wantErr)
+			}
+			if page.gotos != tt.gotos || rotations != tt.rotations {
+				t.Errorf("%d navigations and %d rotations; want %d and %d", page.gotos, rotations, tt.gotos, tt.rotations)
+			}
+		})
+	}
+}
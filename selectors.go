@@ -0,0 +1,75 @@
+package main
+
+// Selectors holds every CSS selector the scraper depends on. Booking.com
+// renames its data-testid attributes from time to time; individual entries
+// can be overridden from the selectors section of the config file instead of
+// patching the code.
+type Selectors struct {
+	PropertyCard   string   `yaml:"property_card"`
+	HeaderTitle    string   `yaml:"header_title"`
+	LoadMoreButton string   `yaml:"load_more_button"`
+	Popups         []string `yaml:"popups"`
+	CAPTCHAFrame   string   `yaml:"captcha_frame"`
+	CAPTCHAVerify  string   `yaml:"captcha_verify"`
+
+	Title          string `yaml:"title"`
+	TitleLink      string `yaml:"title_link"`
+	Price          string `yaml:"price"`
+	ReviewScore    string `yaml:"review_score"`
+	NumReviews     string `yaml:"num_reviews"`
+	Address        string `yaml:"address"`
+	RoomInfo       string `yaml:"room_info"`
+	Cancellation   string `yaml:"cancellation"`
+	PaymentTerms   string `yaml:"payment_terms"`
+	Distance       string `yaml:"distance"`
+	PropertyType   string `yaml:"property_type"`
+	StarRating     string `yaml:"star_rating"`
+	QualityRating  string `yaml:"quality_rating"`
+	ScoreBreakdown string `yaml:"score_breakdown"`
+	Description    string `yaml:"description"`
+	FacilityBadge  string `yaml:"facility_badge"`
+	Image          string `yaml:"image"`
+	MapLink        string `yaml:"map_link"`
+
+	DetailDescription   string `yaml:"detail_description"`
+	DetailCancellation  string `yaml:"detail_cancellation"`
+	DetailHouseRules    string `yaml:"detail_house_rules"`
+	DetailFacilityGroup string `yaml:"detail_facility_group"`
+}
+
+var defaultSelectors = Selectors{
+	PropertyCard:   `div[data-testid="property-card"]`,
+	HeaderTitle:    `h1[data-testid="header-title"]`,
+	LoadMoreButton: `button[data-testid="load-more-results-button"]`,
+	Popups: []string{
+		`button[aria-label="Dismiss sign-in info."]`,
+		`button[aria-label="Close"]`,
+		`#onetrust-accept-btn-handler`,
+	},
+	CAPTCHAFrame:  `iframe[src*="recaptcha"]`,
+	CAPTCHAVerify: `#recaptcha-verify-button`,
+
+	Title:          `div[data-testid="title"]`,
+	TitleLink:      `a[data-testid="title-link"]`,
+	Price:          `span[data-testid="price-and-discounted-price"]`,
+	ReviewScore:    `div[data-testid="review-score"]`,
+	NumReviews:     `div[data-testid="review-score"] ~ div`,
+	Address:        `span[data-testid="address"]`,
+	RoomInfo:       `span[data-testid="room-info"]`,
+	Cancellation:   `span[data-testid="cancellation-policy"]`,
+	PaymentTerms:   `div[data-testid="availability-single"]`,
+	Distance:       `span[data-testid="distance"]`,
+	PropertyType:   `span[data-testid="property-type-badge"]`,
+	StarRating:     `div[data-testid="rating-stars"]`,
+	QualityRating:  `div[data-testid="rating-squares"]`,
+	ScoreBreakdown: `div[data-testid="review-score-breakdown"]`,
+	Description:    `div[data-testid="property-card-description"]`,
+	FacilityBadge:  `div[data-testid="facility-badge"]`,
+	Image:          `img[data-testid="image"]`,
+	MapLink:        `a[href*="#map"]`,
+
+	DetailDescription:   `p[data-testid="property-description"]`,
+	DetailCancellation:  `div[data-testid="policy-cancellation"]`,
+	DetailHouseRules:    `div[data-testid="HouseRules-wrapper"]`,
+	DetailFacilityGroup: `div[data-testid="facility-group-container"]`,
+}
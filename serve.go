@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newServeCommand() *cobra.Command {
+	var addr, dir, listSep string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the CSV output of earlier runs as a JSON API",
+		Long: `Serve answers read-only HTTP requests from the CSV files under the output
+directory, which are re-read on every request so new runs show up without a
+restart:
+
+  GET /cities                          cities with their hotel and file counts
+  GET /hotels?city=Austin&check_in=2024-07-01&limit=50
+                                       hotels, optionally filtered`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server := &hotelServer{dir: dir, listSep: listSep}
+			infof("Serving %s on %s", dir, addr)
+			return http.ListenAndServe(addr, server.routes())
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "Address to listen on")
+	cmd.Flags().StringVar(&dir, "output-dir", envOr("BOOKING_OUTPUT_DIR", "data"), "Directory holding the CSV output to serve (env BOOKING_OUTPUT_DIR)")
+	cmd.Flags().StringVar(&listSep, "list-separator", defaultListSep, "Separator the Amenities and Photos lists were written with")
+	return cmd
+}
+
+type hotelServer struct {
+	dir     string
+	listSep string
+}
+
+func (s *hotelServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cities", s.handleCities)
+	mux.HandleFunc("GET /hotels", s.handleHotels)
+	return mux
+}
+
+// cityInfo is one entry of GET /cities.
+type cityInfo struct {
+	City   string `json:"city"`
+	Hotels int    `json:"hotels"`
+	Files  int    `json:"files"`
+}
+
+func (s *hotelServer) handleCities(w http.ResponseWriter, r *http.Request) {
+	byCity := make(map[string]*cityInfo)
+	err := s.eachFile(func(path string, hotels []Hotel) {
+		seen := make(map[string]bool)
+		for _, hotel := range hotels {
+			info, ok := byCity[hotel.SearchCity]
+			if !ok {
+				info = &cityInfo{City: hotel.SearchCity}
+				byCity[hotel.SearchCity] = info
+			}
+			info.Hotels++
+			if !seen[hotel.SearchCity] {
+				seen[hotel.SearchCity] = true
+				info.Files++
+			}
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cities := make([]cityInfo, 0, len(byCity))
+	for _, info := range byCity {
+		cities = append(cities, *info)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i].City < cities[j].City })
+	writeJSON(w, cities)
+}
+
+func (s *hotelServer) handleHotels(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	city, checkIn := query.Get("city"), query.Get("check_in")
+	if checkIn != "" {
+		if _, err := time.Parse("2006-01-02", checkIn); err != nil {
+			http.Error(w, "check_in must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	matches := []Hotel{}
+	err := s.eachFile(func(path string, hotels []Hotel) {
+		for _, hotel := range hotels {
+			if city != "" && !strings.EqualFold(hotel.SearchCity, city) {
+				continue
+			}
+			if checkIn != "" && hotel.CheckIn != checkIn {
+				continue
+			}
+			matches = append(matches, hotel)
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	writeJSON(w, matches)
+}
+
+// eachFile reads every CSV under the output directory and passes its hotels
+// to fn. Files from before SearchCity was written get the city from their
+// City column or file name.
+func (s *hotelServer) eachFile(fn func(path string, hotels []Hotel)) error {
+	files, err := listCSVFiles(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		// Run summaries share the directory but are not hotel files.
+		if strings.HasPrefix(filepath.Base(path), "summary_") {
+			continue
+		}
+		hotels, err := s.readFile(path)
+		if err != nil {
+			return err
+		}
+		fn(path, hotels)
+	}
+	return nil
+}
+
+func (s *hotelServer) readFile(path string) ([]Hotel, error) {
+	file, err := openCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fallback := strings.ReplaceAll(strings.SplitN(filepath.Base(path), "_hotels_", 2)[0], "_", " ")
+	var hotels []Hotel
+	for {
+		record, err := file.Read()
+		if err == io.EOF {
+			return hotels, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", path, err)
+		}
+		hotel := file.Hotel(record, s.listSep)
+		if hotel.SearchCity == "" {
+			hotel.SearchCity = file.Field(record, "City")
+		}
+		if hotel.SearchCity == "" {
+			hotel.SearchCity = fallback
+		}
+		hotels = append(hotels, hotel)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		errorf("Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RunSummary is written as summary_<timestamp>.json when a run ends, giving
+// CI pipelines something machine-readable to check. Jobs of concurrent
+// cities report into it, hence the mutex.
+type RunSummary struct {
+	mu sync.Mutex
+
+	RunID       string        `json:"run_id"`
+	Started     time.Time     `json:"started"`
+	Finished    time.Time     `json:"finished"`
+	Duration    string        `json:"duration"`
+	Attempted   []string      `json:"cities_attempted"`
+	Succeeded   []string      `json:"cities_succeeded"`
+	Failed      []CityFailure `json:"cities_failed"`
+	TotalHotels int           `json:"total_hotels"`
+	OutputFiles []string      `json:"output_files"`
+	// UploadFailures lists files that could not be copied to S3.
+	UploadFailures []UploadFailure `json:"upload_failures"`
+	// SinkFailures lists jobs whose hotels a database or the combined CSV
+	// did not take.
+	SinkFailures []SinkFailure `json:"sink_failures"`
+	// WebhookFailures counts webhook batches that were not delivered.
+	WebhookFailures int `json:"webhook_failures"`
+	// PublishFailures counts hotels that could not be published to NATS.
+	PublishFailures int `json:"publish_failures"`
+	// Jobs has one entry per finished (city, stay) job, failed or not.
+	Jobs []JobReport `json:"jobs"`
+}
+
+// JobReport describes one finished job. Prices are 0 when no hotel had a
+// parsed price, and Error is set for failed jobs only.
+type JobReport struct {
+	City        string   `json:"city"`
+	Stay        string   `json:"stay"`
+	Hotels      int      `json:"hotels"`
+	MinPrice    float64  `json:"min_price"`
+	MaxPrice    float64  `json:"max_price"`
+	AvgPrice    float64  `json:"avg_price"`
+	Duration    string   `json:"duration,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	Screenshots []string `json:"screenshots,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// UploadFailure records a file that failed to upload.
+type UploadFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// SinkFailure records a job whose hotels could not be saved to a sink.
+type SinkFailure struct {
+	Sink  string `json:"sink"`
+	City  string `json:"city"`
+	Stay  string `json:"stay"`
+	Error string `json:"error"`
+}
+
+// CityFailure records one (city, stay) job that did not complete.
+type CityFailure struct {
+	City         string   `json:"city"`
+	Stay         string   `json:"stay"`
+	Error        string   `json:"error"`
+	PartialFiles []string `json:"partial_files,omitempty"`
+}
+
+// PartialResultsError is returned by a job that failed after collecting
+// hotels, naming the _partial files they were saved to.
+type PartialResultsError struct {
+	Err   error
+	Files []string
+}
+
+func (e *PartialResultsError) Error() string { return e.Err.Error() }
+
+func (e *PartialResultsError) Unwrap() error { return e.Err }
+
+func NewRunSummary(runID string, cities []string) *RunSummary {
+	return &RunSummary{
+		RunID:          runID,
+		Started:        time.Now(),
+		Attempted:      cities,
+		Succeeded:      []string{},
+		Failed:         []CityFailure{},
+		OutputFiles:    []string{},
+		UploadFailures: []UploadFailure{},
+		SinkFailures:   []SinkFailure{},
+		Jobs:           []JobReport{},
+	}
+}
+
+// JobCompleted adds the results of one successful job; files lists every
+// output it wrote, including databases.
+func (s *RunSummary) JobCompleted(report JobReport, files []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.TotalHotels += report.Hotels
+	s.OutputFiles = append(s.OutputFiles, files...)
+	s.Jobs = append(s.Jobs, report)
+}
+
+// JobFailed records a failed job.
+func (s *RunSummary) JobFailed(city string, stay Stay, err error) {
+	var files []string
+	var partial *PartialResultsError
+	if errors.As(err, &partial) {
+		files = partial.Files
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Failed = append(s.Failed, CityFailure{City: city, Stay: stay.String(), Error: err.Error(), PartialFiles: files})
+	s.Jobs = append(s.Jobs, JobReport{City: city, Stay: stay.String(), Files: files, Error: err.Error()})
+}
+
+// UploadFailed records a file that could not be uploaded.
+func (s *RunSummary) UploadFailed(file string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.UploadFailures = append(s.UploadFailures, UploadFailure{File: file, Error: err.Error()})
+}
+
+// SinkFailed records a job whose hotels sink did not save.
+func (s *RunSummary) SinkFailed(sink, city string, stay Stay, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SinkFailures = append(s.SinkFailures, SinkFailure{Sink: sink, City: city, Stay: stay.String(), Error: err.Error()})
+}
+
+// WebhookFailed adds batches that could not be delivered to the webhook.
+func (s *RunSummary) WebhookFailed(batches int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.WebhookFailures += batches
+}
+
+// PublishFailed adds hotels that could not be published.
+func (s *RunSummary) PublishFailed(hotels int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.PublishFailures += hotels
+}
+
+// CitySucceeded records a city whose stays all completed.
+func (s *RunSummary) CitySucceeded(city string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Succeeded = append(s.Succeeded, city)
+}
+
+// Write stamps the finish time and saves the summary under dir.
+func (s *RunSummary) Write(dir string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Finished = time.Now()
+	s.Duration = s.Finished.Sub(s.Started).Round(time.Second).String()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("could not encode run summary: %w", err)
+	}
+	path := filepath.Join(dir, "summary_"+s.Finished.Format("2006-01-02_15-04-05")+".json")
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not write run summary: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newValidateCommand() *cobra.Command {
+	var listSep string
+	cmd := &cobra.Command{
+		Use:   "validate PATH...",
+		Short: "Check output CSV files against the Hotel schema",
+		Long: `Validate reads CSV files, or every CSV under a directory, and reports rows
+that would not pass the scraper's own checks: missing names or prices, bad
+booking URLs, dates and numbers that do not parse, and rows with the wrong
+number of fields. It exits non-zero when any row is invalid.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(cmd.OutOrStdout(), args, listSep)
+		},
+	}
+	cmd.Flags().StringVar(&listSep, "list-separator", defaultListSep, "Separator the Amenities and Photos lists were written with")
+	return cmd
+}
+
+// runValidate validates every CSV under paths, printing one line per
+// problem and one summary line per file.
+func runValidate(w io.Writer, paths []string, listSep string) error {
+	filesChecked, badFiles, badRows := 0, 0, 0
+	for _, path := range paths {
+		files, err := listCSVFiles(path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			rows, problems, err := validateCSVFile(file, listSep)
+			if err != nil {
+				return err
+			}
+			filesChecked++
+			for _, p := range problems {
+				fmt.Fprintf(w, "%s:%s\n", file, p)
+			}
+			if len(problems) > 0 {
+				badFiles++
+				badRows += len(problems)
+			}
+			fmt.Fprintf(w, "%s: %d rows, %d problems\n", file, rows, len(problems))
+		}
+	}
+	if filesChecked == 0 {
+		return fmt.Errorf("no CSV files found")
+	}
+	if badRows > 0 {
+		return fmt.Errorf("%d problems in %d of %d files", badRows, badFiles, filesChecked)
+	}
+	return nil
+}
+
+// validateCSVFile checks the header and every row of one file. Problems
+// are returned as "<line>: <message>"; err is only set when the file
+// cannot be read at all.
+func validateCSVFile(path, listSep string) (rows int, problems []string, err error) {
+	file, err := openCSV(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	known := make(map[string]bool, len(tableHeader)+1)
+	for _, name := range tableHeader {
+		known[name] = true
+	}
+	// Combined files start with the city.
+	known["City"] = true
+	for _, name := range file.Header {
+		if !known[name] {
+			problems = append(problems, fmt.Sprintf("1: unknown column %q", name))
+		}
+	}
+	for _, required := range []string{"Name", "Price", "BookingURL"} {
+		if !file.Has(required) {
+			problems = append(problems, fmt.Sprintf("1: missing required column %s", required))
+		}
+	}
+	if len(problems) > 0 {
+		return 0, problems, nil
+	}
+
+	for {
+		record, err := file.Read()
+		if err == io.EOF {
+			return rows, problems, nil
+		}
+		if err != nil {
+			return rows, nil, fmt.Errorf("could not read %s: %w", path, err)
+		}
+		rows++
+		line, _ := file.FieldPos(0)
+		for _, msg := range validateRecord(file, record, listSep) {
+			problems = append(problems, fmt.Sprintf("%d: %s", line, msg))
+		}
+	}
+}
+
+func validateRecord(file *csvFile, record []string, listSep string) []string {
+	if len(record) != len(file.Header) {
+		return []string{fmt.Sprintf("%d fields, header has %d", len(record), len(file.Header))}
+	}
+
+	var problems []string
+	if err := file.Hotel(record, listSep).Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, name := range []string{"Adults", "Children", "Rooms", "StarRatingInt", "DistanceMeters", "ExtractionMs", "ReviewCount"} {
+		if !file.Has(name) {
+			continue
+		}
+		v := file.Field(record, name)
+		if _, err := strconv.Atoi(v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not an integer", name, v))
+		}
+	}
+	for _, name := range []string{"RatingValue"} {
+		if !file.Has(name) {
+			continue
+		}
+		v := file.Field(record, name)
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not a number", name, v))
+		}
+	}
+	// Unparsed prices and unknown coordinates are written as empty cells.
+	for _, name := range []string{"PriceNumeric", "Latitude", "Longitude"} {
+		if v := file.Field(record, name); v != "" {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %q is not a number", name, v))
+			}
+		}
+	}
+	for _, name := range []string{"CheckIn", "CheckOut"} {
+		if !file.Has(name) {
+			continue
+		}
+		v := file.Field(record, name)
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not a YYYY-MM-DD date", name, v))
+		}
+	}
+	if v := file.Field(record, "ScrapedAt"); v != "" {
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			problems = append(problems, fmt.Sprintf("ScrapedAt %q is not an RFC 3339 time", v))
+		}
+	}
+	optional := map[string]bool{"FreeCancellation": true, "NoPrepayment": true}
+	for _, name := range append([]string{"HasReviews", "FreeCancellation", "NoPrepayment"}, amenityColumns()...) {
+		if !file.Has(name) {
+			continue
+		}
+		v := file.Field(record, name)
+		if v == "" && optional[name] {
+			continue
+		}
+		if _, err := strconv.ParseBool(v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not true or false", name, v))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// webhookAttempts is how often one batch is posted before it counts as
+// failed; only network errors and 5xx responses are retried.
+const webhookAttempts = 5
+
+// webhookTimeout bounds the delivery of one city's batches. Like uploads,
+// deliveries run after a job's context may be close to its deadline.
+const webhookTimeout = 5 * time.Minute
+
+// WebhookSender POSTs a city's hotels as JSON to an internal service when
+// the city finishes, in batches of batchSize. With a secret, each request
+// carries an X-Signature-256 header of the form "sha256=<hex HMAC of the
+// body>" so the receiver can check where it came from.
+type WebhookSender struct {
+	url       string
+	secret    string
+	batchSize int
+	client    *http.Client
+}
+
+func NewWebhookSender(url, secret string, batchSize int) *WebhookSender {
+	return &WebhookSender{
+		url:       url,
+		secret:    secret,
+		batchSize: batchSize,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// webhookPayload is the envelope around each batch.
+type webhookPayload struct {
+	RunID   string  `json:"run_id"`
+	City    string  `json:"city"`
+	Batch   int     `json:"batch"`
+	Batches int     `json:"batches"`
+	Hotels  []Hotel `json:"hotels"`
+}
+
+// Send delivers hotels batch by batch and returns how many batches could not
+// be delivered. A failed batch does not stop the ones after it.
+func (s *WebhookSender) Send(runID, city string, hotels []Hotel, rng *rand.Rand) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	batches := (len(hotels) + s.batchSize - 1) / s.batchSize
+	failed := 0
+	var errs []error
+	for i := 0; i < batches; i++ {
+		batch := hotels[i*s.batchSize : min((i+1)*s.batchSize, len(hotels))]
+		body, err := json.Marshal(webhookPayload{RunID: runID, City: city, Batch: i + 1, Batches: batches, Hotels: batch})
+		if err != nil {
+			return batches, fmt.Errorf("could not encode webhook payload: %w", err)
+		}
+		if err := s.post(ctx, body, rng); err != nil {
+			failed++
+			errs = append(errs, fmt.Errorf("batch %d of %d: %w", i+1, batches, err))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// post sends one body, retrying with exponential backoff.
+func (s *WebhookSender) post(ctx context.Context, body []byte, rng *rand.Rand) error {
+	var err error
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoffDelay(attempt-1, time.Second, rng)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		var retry bool
+		if retry, err = s.postOnce(ctx, body); err == nil || !retry {
+			return err
+		}
+		debugf("Webhook attempt %d failed: %v", attempt+1, err)
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", webhookAttempts, err)
+}
+
+// postOnce makes a single request and reports whether a failure is worth
+// retrying.
+func (s *WebhookSender) postOnce(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("could not create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
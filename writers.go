@@ -0,0 +1,656 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// HotelWriter encodes a city's results in one output format.
+type HotelWriter interface {
+	// Ext is the file extension, without the leading dot.
+	Ext() string
+	Write(w io.Writer, hotels []Hotel) error
+}
+
+// commonAmenities are the amenities that get a boolean column of their own
+// in tabular output. An amenity matches when any entry of AmenitiesList
+// contains the pattern, so "Free WiFi" and "WiFi available in all areas"
+// both count as WiFi.
+var commonAmenities = []struct {
+	Column  string
+	pattern *regexp.Regexp
+}{
+	{"HasFreeWifi", regexp.MustCompile(`(?i)\bfree wi-?fi\b`)},
+	{"HasParking", regexp.MustCompile(`(?i)\bparking\b`)},
+	{"HasPool", regexp.MustCompile(`(?i)\bpool\b`)},
+	{"HasBreakfast", regexp.MustCompile(`(?i)\bbreakfast\b`)},
+	{"HasAirConditioning", regexp.MustCompile(`(?i)\bair conditioning\b`)},
+	{"HasFitnessCenter", regexp.MustCompile(`(?i)\b(fitness|gym)\b`)},
+	{"HasRestaurant", regexp.MustCompile(`(?i)\brestaurant\b`)},
+	{"HasPetsAllowed", regexp.MustCompile(`(?i)\bpets (are )?allowed\b`)},
+	{"HasAirportShuttle", regexp.MustCompile(`(?i)\bairport shuttle\b`)},
+	{"HasSpa", regexp.MustCompile(`(?i)\bspa\b`)},
+}
+
+// hasAmenity reports whether any entry of list matches pattern.
+func hasAmenity(list []string, pattern *regexp.Regexp) bool {
+	for _, amenity := range list {
+		if pattern.MatchString(amenity) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableHeader is the column order shared by the tabular formats (CSV and
+// XLSX); tableRow must list the fields in the same order.
+var tableHeader = append([]string{"Name", "Price", "CheckIn", "CheckOut", "Rating", "NumReviews", "Address", "Amenities", "RoomType", "Cancellation", "Distance", "PropertyType", "StarRating", "BookingURL", "Photos", "GuestScoreBreak", "Description", "Adults", "Children", "Rooms", "Currency", "HouseRules", "Language", "PriceNumeric", "StarRatingInt", "DistanceMeters", "ScrapedAt", "RunID", "SearchCity", "ExtractionMs", "RatingValue", "ReviewCount", "HasReviews", "StarRatingType", "PropertyID", "CanonicalURL", "Latitude", "Longitude", "FreeCancellation", "NoPrepayment", "PriceCurrency"}, amenityColumns()...)
+
+func amenityColumns() []string {
+	columns := make([]string, len(commonAmenities))
+	for i, a := range commonAmenities {
+		columns[i] = a.Column
+	}
+	return columns
+}
+
+// coordinate formats Latitude or Longitude, leaving the cell empty when the
+// hotel has no coordinates.
+func coordinate(hotel Hotel, v float64) string {
+	if !hotel.HasCoordinates() {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// priceCell formats PriceNumeric, leaving the cell empty when the price
+// could not be parsed.
+func priceCell(v float64) string {
+	if v <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// optionalBool formats a flag that may be unknown as true, false or empty.
+func optionalBool(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
+
+func tableRow(hotel Hotel) []string {
+	row := []string{
+		hotel.Name, hotel.Price, hotel.CheckIn, hotel.CheckOut, hotel.Rating, hotel.NumReviews,
+		hotel.Address, hotel.Amenities, hotel.RoomType, hotel.Cancellation, hotel.Distance,
+		hotel.PropertyType, hotel.StarRating, hotel.BookingURL, hotel.Photos, hotel.GuestScoreBreak,
+		hotel.Description, strconv.Itoa(hotel.Adults), strconv.Itoa(hotel.Children), strconv.Itoa(hotel.Rooms),
+		hotel.Currency, hotel.HouseRules, hotel.Language, priceCell(hotel.PriceNumeric),
+		strconv.Itoa(hotel.StarRatingInt), strconv.Itoa(hotel.DistanceMeters), hotel.ScrapedAt, hotel.RunID, hotel.SearchCity,
+		strconv.FormatInt(hotel.ExtractionMs, 10), strconv.FormatFloat(hotel.RatingValue, 'f', -1, 64),
+		strconv.Itoa(hotel.ReviewCount), strconv.FormatBool(hotel.HasReviews), hotel.StarRatingType,
+		hotel.PropertyID, hotel.CanonicalURL, coordinate(hotel, hotel.Latitude), coordinate(hotel, hotel.Longitude),
+		optionalBool(hotel.FreeCancellation), optionalBool(hotel.NoPrepayment),
+		hotel.PriceCurrency,
+	}
+	for _, a := range commonAmenities {
+		row = append(row, strconv.FormatBool(hasAmenity(hotel.AmenitiesList, a.pattern)))
+	}
+	return row
+}
+
+// Columns selects and orders tabular columns as indexes into tableHeader.
+// A nil Columns keeps every column in the default order.
+type Columns []int
+
+// parseColumns turns a -columns value such as "name,price,rating" into
+// Columns. Names are matched against tableHeader without regard to case.
+func parseColumns(value string) (Columns, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var columns Columns
+	seen := make(map[int]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		index := -1
+		for i, column := range tableHeader {
+			if strings.EqualFold(column, name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column %q: valid columns are %s", name, strings.Join(tableHeader, ", "))
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("column %q is listed twice", name)
+		}
+		seen[index] = true
+		columns = append(columns, index)
+	}
+	return columns, nil
+}
+
+// Header returns the names of the selected columns.
+func (c Columns) Header() []string {
+	return c.Row(tableHeader)
+}
+
+// Row picks the selected columns out of a full tableRow.
+func (c Columns) Row(row []string) []string {
+	if c == nil {
+		return row
+	}
+	selected := make([]string, len(c))
+	for i, index := range c {
+		selected[i] = row[index]
+	}
+	return selected
+}
+
+// CSVWriter writes one row per hotel with a header line. Comma is the field
+// delimiter, ',' when unset. When ListSep is set, Amenities and Photos are
+// written as AmenitiesList and PhotosList joined with it, so the delimiter
+// never appears inside a list; otherwise they stay as the joined strings
+// scraped from the page. Columns selects the columns written, and BOM
+// prefixes the file with a UTF-8 byte order mark so Excel on Windows does not
+// garble accented names.
+type CSVWriter struct {
+	Comma   rune
+	ListSep string
+	Columns Columns
+	BOM     bool
+}
+
+// utf8BOM is the UTF-8 encoding of U+FEFF.
+const utf8BOM = "\ufeff"
+
+// writeStart writes the BOM, if enabled, and the header row.
+func (c CSVWriter) writeStart(w io.Writer, writer *csv.Writer) error {
+	if c.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return fmt.Errorf("error writing BOM to CSV: %w", err)
+		}
+	}
+	if err := writer.Write(c.Columns.Header()); err != nil {
+		return fmt.Errorf("error writing header to CSV: %w", err)
+	}
+	return nil
+}
+
+// Ext is "tsv" for tab-separated output and "csv" otherwise.
+func (c CSVWriter) Ext() string {
+	if c.Comma == '\t' {
+		return "tsv"
+	}
+	return "csv"
+}
+
+func (c CSVWriter) newWriter(w io.Writer) *csv.Writer {
+	writer := csv.NewWriter(w)
+	if c.Comma != 0 {
+		writer.Comma = c.Comma
+	}
+	return writer
+}
+
+func (c CSVWriter) row(hotel Hotel) []string {
+	if c.ListSep != "" {
+		if hotel.Amenities != "N/A" {
+			hotel.Amenities = strings.Join(hotel.amenities(), c.ListSep)
+		}
+		if hotel.Photos != "N/A" {
+			hotel.Photos = strings.Join(hotel.photos(), c.ListSep)
+		}
+	}
+	return c.Columns.Row(tableRow(hotel))
+}
+
+func (c CSVWriter) Write(w io.Writer, hotels []Hotel) error {
+	writer := c.newWriter(w)
+
+	if err := c.writeStart(w, writer); err != nil {
+		return err
+	}
+
+	for _, hotel := range hotels {
+		if err := writer.Write(c.row(hotel)); err != nil {
+			return fmt.Errorf("error writing row to CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// JSONWriter writes all hotels as a single indented JSON array.
+type JSONWriter struct{}
+
+func (JSONWriter) Ext() string { return "json" }
+
+func (JSONWriter) Write(w io.Writer, hotels []Hotel) error {
+	if hotels == nil {
+		hotels = []Hotel{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(hotels); err != nil {
+		return fmt.Errorf("error writing JSON: %w", err)
+	}
+	return nil
+}
+
+// JSONLWriter writes one JSON object per line, with Amenities and Photos
+// split into arrays so they survive without a second round of parsing. When
+// Columns is set, each object holds only those keys, in that order.
+type JSONLWriter struct {
+	Columns Columns
+}
+
+func (JSONLWriter) Ext() string { return "jsonl" }
+
+func (j JSONLWriter) Write(w io.Writer, hotels []Hotel) error {
+	type jsonlHotel struct {
+		Hotel
+		Amenities []string
+		Photos    []string
+		// The lists are written as Amenities and Photos already.
+		AmenitiesList []string `json:"-"`
+		PhotosList    []string `json:"-"`
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, hotel := range hotels {
+		record := jsonlHotel{
+			Hotel:     hotel,
+			Amenities: hotel.amenities(),
+			Photos:    hotel.photos(),
+		}
+		if j.Columns != nil {
+			line, err := selectJSONColumns(record, hotel, j.Columns)
+			if err != nil {
+				return fmt.Errorf("error writing JSON line: %w", err)
+			}
+			if _, err := w.Write(line); err != nil {
+				return fmt.Errorf("error writing JSON line: %w", err)
+			}
+			continue
+		}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error writing JSON line: %w", err)
+		}
+	}
+	return nil
+}
+
+// selectJSONColumns encodes the chosen columns of record as one JSON line.
+// encoding/json sorts map keys, so the object is assembled by hand to keep
+// the order given in -columns. The amenity flags are not fields of record
+// and come from the tabular row instead.
+func selectJSONColumns(record any, hotel Hotel, columns Columns) ([]byte, error) {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	row := tableRow(hotel)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, index := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name := tableHeader[index]
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if value, ok := fields[name]; ok {
+			buf.Write(value)
+		} else {
+			buf.WriteString(row[index])
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// amenities and photos return the lists Amenities and Photos were joined
+// from. The joined strings are never split again, since entries such as
+// "Parking: Free, on-site" contain the separators themselves; a hotel built
+// without the lists counts its joined string as a single entry.
+func (h Hotel) amenities() []string { return listOf(h.AmenitiesList, h.Amenities) }
+
+func (h Hotel) photos() []string { return listOf(h.PhotosList, h.Photos) }
+
+func listOf(list []string, joined string) []string {
+	switch {
+	case list != nil:
+		return list
+	case joined == "" || joined == "N/A":
+		return []string{}
+	default:
+		return []string{joined}
+	}
+}
+
+// parseDelimiter turns a -delimiter value into a CSV field separator. Tabs
+// may be given as "\t" or "tab" since a literal tab is awkward in a shell.
+func parseDelimiter(value string) (rune, error) {
+	switch value {
+	case ",":
+		return ',', nil
+	case ";":
+		return ';', nil
+	case "\t", "\\t", "tab":
+		return '\t', nil
+	}
+	return 0, fmt.Errorf("unsupported delimiter %q: must be \",\", \";\" or \"\\t\"", value)
+}
+
+// parseFormats turns a -format value into writers. It accepts a
+// comma-separated list of csv, json, jsonl, parquet, xlsx and arrow; "both"
+// means csv,json. CSV output is written with csvWriter; JSONL and XLSX output
+// is limited to columns.
+func parseFormats(value string, csvWriter CSVWriter, columns Columns) ([]HotelWriter, error) {
+	var writers []HotelWriter
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		var names []string
+		if name == "both" {
+			names = []string{"csv", "json"}
+		} else {
+			names = []string{name}
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			switch name {
+			case "csv":
+				writers = append(writers, csvWriter)
+			case "json":
+				writers = append(writers, JSONWriter{})
+			case "jsonl":
+				writers = append(writers, JSONLWriter{Columns: columns})
+			case "parquet":
+				writers = append(writers, ParquetWriter{})
+			case "xlsx":
+				writers = append(writers, XLSXWriter{Columns: columns})
+			case "arrow":
+				writers = append(writers, ArrowWriter{})
+			default:
+				return nil, fmt.Errorf("unknown format %q: must be csv, json, jsonl, parquet, xlsx, arrow or both", name)
+			}
+		}
+	}
+	return writers, nil
+}
+
+// outputExt returns the file extension for w, with .gz appended when the
+// output is compressed.
+func outputExt(w HotelWriter, compress bool) string {
+	if compress {
+		return w.Ext() + ".gz"
+	}
+	return w.Ext()
+}
+
+// exportHotels writes hotels with w to a new file under outputDir, gzipped
+// when compress is set, and returns its path.
+func exportHotels(w HotelWriter, hotels []Hotel, outputDir, city string, checkIn, checkOut time.Time, compress bool) (string, error) {
+	filePath, err := outputPath(outputDir, city, checkIn, checkOut, outputExt(w, compress))
+	if err != nil {
+		return "", err
+	}
+
+	err = writeFileAtomic(filePath, func(dst io.Writer) error {
+		return encodeHotels(w, dst, hotels, compress)
+	})
+	if err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// writeFileAtomic writes path through <path>.tmp, which is synced and then
+// renamed into place, so a crash mid-write never leaves a truncated file
+// under the final name. The temporary file is removed when write fails.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+
+	err = write(file)
+	if err == nil {
+		if err = file.Sync(); err != nil {
+			err = fmt.Errorf("could not flush %s: %w", tmp, err)
+		}
+	}
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("could not close %s: %w", tmp, closeErr)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return renameFile(tmp, path)
+}
+
+// renameFile moves src to dst, copying and deleting when a rename is not
+// possible because they are on different file systems.
+func renameFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("could not rename %s: %w", src, err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("could not copy %s to %s: %w", src, dst, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("could not copy %s to %s: %w", src, dst, err)
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// stdoutMu keeps the output of cities that finish at the same time from
+// interleaving on stdout.
+var stdoutMu sync.Mutex
+
+// exportToStdout writes hotels with w to standard output.
+func exportToStdout(w HotelWriter, hotels []Hotel, compress bool) error {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+	return encodeHotels(w, os.Stdout, hotels, compress)
+}
+
+// encodeHotels writes hotels with w to dst, gzipped when compress is set.
+func encodeHotels(w HotelWriter, dst io.Writer, hotels []Hotel, compress bool) error {
+	if !compress {
+		return w.Write(dst, hotels)
+	}
+
+	// The gzip stream is closed even after a failed write so the output is
+	// at least a valid, if truncated, archive.
+	gz := gzip.NewWriter(dst)
+	writeErr := w.Write(gz, hotels)
+	if err := gz.Close(); err != nil && writeErr == nil {
+		writeErr = fmt.Errorf("error finishing gzip stream: %w", err)
+	}
+	return writeErr
+}
+
+// CSVStream appends hotels to a CSV file while they are being extracted, so
+// a city that fails or times out still leaves its rows on disk. Rows are
+// flushed every flushEvery hotels.
+type CSVStream struct {
+	file       *os.File
+	format     CSVWriter
+	writer     *csv.Writer
+	path       string
+	rows       int
+	flushEvery int
+}
+
+// NewCSVStream creates path and writes the header in the given format.
+func NewCSVStream(path string, flushEvery int, format CSVWriter) (*CSVStream, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create file: %w", err)
+	}
+
+	s := &CSVStream{file: file, format: format, writer: format.newWriter(file), path: path, flushEvery: flushEvery}
+	if err := format.writeStart(file, s.writer); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add writes one hotel, flushing to disk every flushEvery rows.
+func (s *CSVStream) Add(hotel Hotel) error {
+	if err := s.writer.Write(s.format.row(hotel)); err != nil {
+		return fmt.Errorf("error writing row to CSV: %w", err)
+	}
+	s.rows++
+	if s.rows%s.flushEvery == 0 {
+		s.writer.Flush()
+		if err := s.writer.Error(); err != nil {
+			return fmt.Errorf("error flushing CSV: %w", err)
+		}
+	}
+	return nil
+}
+
+// Rows returns the number of hotels written so far.
+func (s *CSVStream) Rows() int {
+	return s.rows
+}
+
+// Path returns the location of the stream file.
+func (s *CSVStream) Path() string {
+	return s.path
+}
+
+// Close flushes the remaining rows and closes the file.
+func (s *CSVStream) Close() error {
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("error flushing CSV: %w", err)
+	}
+	return s.file.Close()
+}
+
+// savePartialResults keeps what a failed job collected, next to where its
+// outputs would have gone but with a _partial suffix. hotels are written in
+// every per-city output format when extraction got that far. The closed
+// stream is renamed instead when there are no such formats (-combined with
+// only csv), or when the CSV among them failed or none succeeded; a partial
+// CSV that was written is never replaced by the stream. With -compress the
+// stream is gzipped too. It returns the files kept.
+func savePartialResults(cfg Config, hotels Hotels, stream *CSVStream) ([]string, error) {
+	base := strings.TrimSuffix(stream.Path(), ".partial."+cfg.CSV.Ext())
+
+	var files []string
+	var errs []error
+	if len(hotels) > 0 {
+		csvSaved := false
+		for _, w := range cfg.Writers {
+			filePath := base + "_partial." + outputExt(w, cfg.Compress)
+			err := writeFileAtomic(filePath, func(dst io.Writer) error {
+				return encodeHotels(w, dst, hotels, cfg.Compress)
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error writing partial %s: %w", strings.ToUpper(w.Ext()), err))
+				continue
+			}
+			files = append(files, filePath)
+			if w.Ext() == cfg.CSV.Ext() {
+				csvSaved = true
+			}
+		}
+		if csvSaved || (len(errs) == 0 && len(files) > 0) {
+			os.Remove(stream.Path())
+			return files, errors.Join(errs...)
+		}
+	}
+
+	if stream.Rows() > 0 {
+		filePath, err := keepStream(stream.Path(), base+"_partial."+cfg.CSV.Ext(), cfg.Compress)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			files = append(files, filePath)
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+// keepStream moves a closed stream to filePath and returns where it went.
+// With compress it is gzipped on the way to filePath.gz, like every other
+// output.
+func keepStream(streamPath, filePath string, compress bool) (string, error) {
+	if !compress {
+		return filePath, renameFile(streamPath, filePath)
+	}
+
+	filePath += ".gz"
+	src, err := os.Open(streamPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	err = writeFileAtomic(filePath, func(dst io.Writer) error {
+		gz := gzip.NewWriter(dst)
+		if _, err := io.Copy(gz, src); err != nil {
+			gz.Close()
+			return err
+		}
+		return gz.Close()
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not compress %s: %w", streamPath, err)
+	}
+	src.Close()
+	return filePath, os.Remove(streamPath)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVWriterBOM(t *testing.T) {
+	hotels := Hotels{{Name: "Hôtel Plaza Athénée", Price: "€ 1.250"}}
+	for _, bom := range []bool{false, true} {
+		var buf strings.Builder
+		if err := (CSVWriter{BOM: bom, Columns: Columns{0, 1}}).Write(&buf, hotels); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		want := "Name,Price\nHôtel Plaza Athénée,€ 1.250\n"
+		if bom {
+			want = "\xef\xbb\xbf" + want
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("BOM %v: got %q; want %q", bom, got, want)
+		}
+	}
+}
+
+func TestCSVStreamBOM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.partial.csv")
+	stream, err := NewCSVStream(path, 1, CSVWriter{BOM: true, Columns: Columns{0}})
+	if err != nil {
+		t.Fatalf("NewCSVStream: %v", err)
+	}
+	if err := stream.Add(Hotel{Name: "Adlon"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\xef\xbb\xbfName\nAdlon\n"; string(data) != want {
+		t.Errorf("got %q; want %q", data, want)
+	}
+}
+
+// awkwardDescriptions need quoting or escaping in CSV and JSON.
+var awkwardDescriptions = []string{
+	"Line one\nline two",
+	"Pool, spa, and gym",
+	`The "Grand" suite`,
+	"Mixed: \"quoted, with comma\"\r\nand a CRLF",
+}
+
+func TestCSVWriterQuoting(t *testing.T) {
+	var hotels Hotels
+	for _, d := range awkwardDescriptions {
+		hotels = append(hotels, Hotel{Name: "Adlon", Description: d})
+	}
+	for _, comma := range []rune{',', '\t'} {
+		var buf strings.Builder
+		if err := (CSVWriter{Comma: comma}).Write(&buf, hotels); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+
+		r := csv.NewReader(strings.NewReader(buf.String()))
+		r.Comma = comma
+		records, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("comma %q: reading back: %v", comma, err)
+		}
+		if len(records) != len(hotels)+1 {
+			t.Fatalf("comma %q: %d records; want %d", comma, len(records), len(hotels)+1)
+		}
+		column := slices.Index(records[0], "Description")
+		for i, want := range awkwardDescriptions {
+			// encoding/csv turns \r\n inside quoted fields into \n.
+			want = strings.ReplaceAll(want, "\r\n", "\n")
+			if got := records[i+1][column]; got != want {
+				t.Errorf("comma %q: Description = %q; want %q", comma, got, want)
+			}
+		}
+	}
+}
+
+func TestJSONLWriterEscaping(t *testing.T) {
+	var hotels Hotels
+	for _, d := range awkwardDescriptions {
+		hotels = append(hotels, Hotel{Name: "Adlon", Description: d})
+	}
+	var buf strings.Builder
+	if err := (JSONLWriter{}).Write(&buf, hotels); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(hotels) {
+		t.Fatalf("%d lines; want one per hotel, %d", lines, len(hotels))
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(buf.String()))
+	for _, want := range awkwardDescriptions {
+		var record struct{ Description string }
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if record.Description != want {
+			t.Errorf("Description = %q; want %q", record.Description, want)
+		}
+	}
+}
+
+// With -combined -format csv there are no per-city writers, so the stream
+// itself has to be kept instead of being removed with nothing in its place.
+func TestSavePartialResultsWithoutWriters(t *testing.T) {
+	csvWriter := CSVWriter{Columns: Columns{0}}
+	tests := []struct {
+		name    string
+		writers []HotelWriter
+		want    []string
+		csv     string
+		wantErr bool
+	}{
+		{"no writers", nil, []string{"csv"}, "Name\nAdlon\n", false},
+		{"all written", []HotelWriter{csvWriter, JSONLWriter{}}, []string{"csv", "jsonl"}, "Name\nAdlon\nRitz\n", false},
+		{"csv written, jsonl failed", []HotelWriter{csvWriter, failingWriter{"jsonl"}}, []string{"csv"}, "Name\nAdlon\nRitz\n", true},
+		{"csv failed", []HotelWriter{failingWriter{"csv"}, JSONLWriter{}}, []string{"jsonl", "csv"}, "Name\nAdlon\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Paris_2024-05-01_2024-05-02.partial.csv")
+			stream, err := NewCSVStream(path, 1, csvWriter)
+			if err != nil {
+				t.Fatalf("NewCSVStream: %v", err)
+			}
+			// The stream only got as far as the first hotel.
+			hotels := Hotels{{Name: "Adlon"}, {Name: "Ritz"}}
+			if err := stream.Add(hotels[0]); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			if err := stream.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			files, err := savePartialResults(Config{CSV: csvWriter, Writers: tt.writers}, hotels, stream)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("savePartialResults error = %v; want error %v", err, tt.wantErr)
+			}
+			base := strings.TrimSuffix(path, ".partial.csv") + "_partial."
+			var want []string
+			for _, ext := range tt.want {
+				want = append(want, base+ext)
+			}
+			if !slices.Equal(files, want) {
+				t.Fatalf("files = %v; want %v", files, want)
+			}
+			data, err := os.ReadFile(base + "csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.csv {
+				t.Errorf("kept %q; want %q", data, tt.csv)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("stream %s still exists: %v", path, err)
+			}
+		})
+	}
+}
+
+// With -compress the stream kept in place of the writers' output is gzipped
+// like every other file.
+func TestSavePartialResultsCompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Paris_2024-05-01_2024-05-02.partial.csv")
+	stream, err := NewCSVStream(path, 1, CSVWriter{Columns: Columns{0}})
+	if err != nil {
+		t.Fatalf("NewCSVStream: %v", err)
+	}
+	if err := stream.Add(Hotel{Name: "Adlon"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files, err := savePartialResults(Config{CSV: CSVWriter{}, Compress: true}, nil, stream)
+	if err != nil {
+		t.Fatalf("savePartialResults: %v", err)
+	}
+	want := strings.TrimSuffix(path, ".partial.csv") + "_partial.csv.gz"
+	if !slices.Equal(files, []string{want}) {
+		t.Fatalf("files = %v; want [%s]", files, want)
+	}
+
+	f, err := os.Open(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s is not gzipped: %v", want, err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Name\nAdlon\n" {
+		t.Errorf("kept %q; want the streamed rows", data)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stream %s still exists: %v", path, err)
+	}
+}
+
+// A write that fails halfway must leave neither the final file nor the
+// .tmp it was writing to.
+func TestWriteFileAtomicFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Paris.csv")
+	errWrite := errors.New("disk full")
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "Name\nAdlon\n"); err != nil {
+			return err
+		}
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("writeFileAtomic = %v; want %v", err, errWrite)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists after a failed write: %v", path, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("%s.tmp was not removed: %v", path, err)
+	}
+}
+
+// failingWriter writes part of its output and then fails, like a disk that
+// fills up mid-export.
+type failingWriter struct{ ext string }
+
+func (w failingWriter) Ext() string { return w.ext }
+
+func (failingWriter) Write(w io.Writer, hotels []Hotel) error {
+	if _, err := io.WriteString(w, "Name\n"); err != nil {
+		return err
+	}
+	return errors.New("disk full")
+}
+
+func TestExportHotelsFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, compress := range []bool{false, true} {
+		if _, err := exportHotels(failingWriter{"csv"}, Hotels{{Name: "Adlon"}}, dir, "Paris", day, day.AddDate(0, 0, 1), compress); err == nil {
+			t.Errorf("compress %v: exportHotels succeeded; want error", compress)
+		}
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			t.Errorf("failed export left %s", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Auto-sized columns are clamped to these widths so a long address does not
+// produce an absurdly wide sheet.
+const (
+	xlsxMinWidth = 8
+	xlsxMaxWidth = 60
+)
+
+// xlsxAutoSized lists the key columns that get sized to their content; the
+// rest keep the default width.
+var xlsxAutoSized = map[string]bool{
+	"Name": true, "Price": true, "CheckIn": true, "CheckOut": true, "Rating": true,
+	"NumReviews": true, "Address": true, "RoomType": true, "Distance": true, "PropertyType": true,
+}
+
+// XLSXWriter writes an Excel workbook per city, named like the CSV files,
+// with a frozen header row and the same columns as the CSV output. Unlike a
+// CSV opened in Excel, it keeps UTF-8 hotel names intact. Columns selects
+// the columns written.
+type XLSXWriter struct {
+	Columns Columns
+}
+
+func (XLSXWriter) Ext() string { return "xlsx" }
+
+func (x XLSXWriter) Write(w io.Writer, hotels []Hotel) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	header := x.Columns.Header()
+	widths := make([]int, len(header))
+	writeRow := func(row int, values []string) error {
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			widths[i] = max(widths[i], len([]rune(v)))
+		}
+		return f.SetSheetRow(sheet, cell, &values)
+	}
+
+	if err := writeRow(1, header); err != nil {
+		return fmt.Errorf("error writing header to XLSX: %w", err)
+	}
+	for i, hotel := range hotels {
+		if err := writeRow(i+2, x.Columns.Row(tableRow(hotel))); err != nil {
+			return fmt.Errorf("error writing row to XLSX: %w", err)
+		}
+	}
+
+	if err := f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return fmt.Errorf("error freezing XLSX header: %w", err)
+	}
+
+	for i, name := range header {
+		if !xlsxAutoSized[name] {
+			continue
+		}
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		width := float64(min(max(widths[i]+2, xlsxMinWidth), xlsxMaxWidth))
+		if err := f.SetColWidth(sheet, col, col, width); err != nil {
+			return fmt.Errorf("error sizing XLSX column %s: %w", name, err)
+		}
+	}
+
+	if err := f.Write(w); err != nil {
+		return fmt.Errorf("error writing XLSX: %w", err)
+	}
+	return nil
+}